@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clusterapi implements a CloudProvider backed by Cluster API
+// MachineDeployment/MachineSet objects, for Charmed Kubernetes clusters that
+// front their machine lifecycle with CAPI plus a Juju/MAAS infrastructure
+// provider rather than driving the Juju controller directly.
+package clusterapi
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	klog "k8s.io/klog/v2"
+)
+
+var _ cloudprovider.CloudProvider = (*clusterAPICloudProvider)(nil)
+
+const (
+	// ProviderName is the name reported by clusterAPICloudProvider.Name().
+	ProviderName = "clusterapi"
+
+	// GPULabel is the label added to nodes with GPU resource.
+	GPULabel = "cluster-api/gpu-node"
+
+	scaleToZeroSupported = true
+)
+
+// clusterAPICloudProvider implements CloudProvider by watching
+// MachineDeployment/MachineSet objects annotated for autoscaling.
+type clusterAPICloudProvider struct {
+	resourceLimiter *cloudprovider.ResourceLimiter
+	controller      *machineController
+	nodeGroups      []cloudprovider.NodeGroup
+}
+
+func newClusterAPICloudProvider(rl *cloudprovider.ResourceLimiter, controller *machineController) (*clusterAPICloudProvider, error) {
+	return &clusterAPICloudProvider{
+		resourceLimiter: rl,
+		controller:      controller,
+	}, nil
+}
+
+// Name returns name of the cloud provider.
+func (p *clusterAPICloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (p *clusterAPICloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	return p.nodeGroups
+}
+
+// NodeGroupForNode returns the node group for the given node, nil if the
+// node should not be processed by cluster autoscaler, or non-nil error if
+// such occurred.
+func (p *clusterAPICloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	return p.controller.nodeGroupForNode(node)
+}
+
+// Pricing returns pricing model for this cloud provider or error if not
+// available. Implementation optional.
+func (p *clusterAPICloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes get all machine types that can be requested from
+// the cloud provider. Implementation optional.
+func (p *clusterAPICloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup builds a theoretical node group based on the node definition
+// provided. Implementation optional.
+func (p *clusterAPICloudProvider) NewNodeGroup(
+	machineType string,
+	labels map[string]string,
+	systemLabels map[string]string,
+	taints []apiv1.Taint,
+	extraResources map[string]resource.Quantity,
+) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for
+// resources (cores, memory etc.).
+func (p *clusterAPICloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return p.resourceLimiter, nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (p *clusterAPICloudProvider) GPULabel() string {
+	return GPULabel
+}
+
+// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+func (p *clusterAPICloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	return nil
+}
+
+// Cleanup cleans up open resources before the cloud provider is destroyed,
+// i.e. go routines etc.
+func (p *clusterAPICloudProvider) Cleanup() error {
+	return p.controller.stop()
+}
+
+// Refresh is called before every main loop and can be used to dynamically
+// update cloud provider state. The list of node groups can change as a
+// result of MachineDeployments/MachineSets being created, resized, or
+// removed from the scope being watched.
+func (p *clusterAPICloudProvider) Refresh() error {
+	nodeGroups, err := p.controller.nodeGroups()
+	if err != nil {
+		return err
+	}
+	p.nodeGroups = nodeGroups
+	return nil
+}
+
+// clusterAPICloudConfig is the cloud-config YAML understood by this
+// provider, mirroring the shape of the juju provider's own jujuCloudConfig.
+type clusterAPICloudConfig struct {
+	// Namespace restricts machine-group discovery to a single namespace. An
+	// empty value watches MachineDeployments/MachineSets across all
+	// namespaces, i.e. cross-namespace deployments are supported by default.
+	Namespace string `yaml:"namespace"`
+}
+
+// BuildClusterAPI builds the Cluster API cloud provider.
+func BuildClusterAPI(
+	opts config.AutoscalingOptions,
+	do cloudprovider.NodeGroupDiscoveryOptions,
+	rl *cloudprovider.ResourceLimiter,
+) cloudprovider.CloudProvider {
+	dynamicClient, err := buildDynamicClient()
+	if err != nil {
+		klog.Fatalf("failed to build Cluster API dynamic client: %v", err)
+	}
+
+	cloudConfig, err := readCloudConfig(opts.CloudConfig)
+	if err != nil {
+		klog.Fatalf("couldn't read cloud provider configuration %s: %v", opts.CloudConfig, err)
+	}
+
+	controller, err := newMachineController(dynamicClient, cloudConfig.Namespace)
+	if err != nil {
+		klog.Fatalf("failed to start Cluster API machine controller: %v", err)
+	}
+	if err := controller.run(); err != nil {
+		klog.Fatalf("failed to run Cluster API machine controller: %v", err)
+	}
+
+	provider, err := newClusterAPICloudProvider(rl, controller)
+	if err != nil {
+		klog.Fatalf("failed to create Cluster API cloud provider: %v", err)
+	}
+
+	if err := provider.Refresh(); err != nil {
+		klog.Fatalf("failed initial refresh of Cluster API node groups: %v", err)
+	}
+
+	return provider
+}
+
+func readCloudConfig(path string) (clusterAPICloudConfig, error) {
+	cfg := clusterAPICloudConfig{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	configRC, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+	defer configRC.Close()
+
+	b, err := io.ReadAll(configRC)
+	if err != nil {
+		return cfg, err
+	}
+
+	err = yaml.Unmarshal(b, &cfg)
+	return cfg, err
+}
+
+func buildDynamicClient() (dynamic.Interface, error) {
+	kubeConfigFile := flag.Lookup("kubeconfig").Value.(flag.Getter).Get().(string)
+	var cfg *rest.Config
+	var err error
+	if kubeConfigFile != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeConfigFile)
+	} else {
+		cfg, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig for Cluster API client: %v", err)
+	}
+	return dynamic.NewForConfig(cfg)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// minSizeAnnotation is read off a MachineDeployment/MachineSet to mark
+	// it as managed by the autoscaler and set its minimum size.
+	minSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-min-size"
+	// maxSizeAnnotation is the matching maximum-size annotation.
+	maxSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-max-size"
+	// deleteMachineAnnotation marks a specific Machine for removal the next
+	// time its owning MachineSet's replica count is decremented.
+	deleteMachineAnnotation = "cluster.x-k8s.io/delete-machine"
+
+	resyncPeriod = 10 * time.Minute
+)
+
+var (
+	machineDeploymentGVR = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinedeployments"}
+	machineSetGVR        = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinesets"}
+	machineGVR           = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machines"}
+)
+
+// machineController discovers node groups by watching MachineDeployment and
+// MachineSet objects across the configured namespace scope, and resolves
+// node ownership through the Machine -> Node provider-ID mapping.
+type machineController struct {
+	dynamicClient dynamic.Interface
+	namespace     string
+
+	informerFactory           dynamicinformer.DynamicSharedInformerFactory
+	machineDeploymentInformer cache.SharedIndexInformer
+	machineSetInformer        cache.SharedIndexInformer
+	machineInformer           cache.SharedIndexInformer
+
+	stopCh chan struct{}
+
+	mu sync.Mutex
+	// nodeGroups is keyed by NodeGroup.id (see getOrCreateNodeGroup).
+	nodeGroups map[string]*NodeGroup
+	// machineSetOwner maps "namespace/machineSetName" to the id of the
+	// NodeGroup that owns scaling decisions for Machines in that
+	// MachineSet: for a MachineSet owned by a MachineDeployment, the
+	// MachineDeployment's NodeGroup, and only when that MachineDeployment
+	// is annotated for autoscaling; otherwise the MachineSet's own NodeGroup.
+	machineSetOwner map[string]string
+}
+
+func newMachineController(dynamicClient dynamic.Interface, namespace string) (*machineController, error) {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, resyncPeriod, namespace, nil)
+
+	return &machineController{
+		dynamicClient:             dynamicClient,
+		namespace:                 namespace,
+		informerFactory:           factory,
+		machineDeploymentInformer: factory.ForResource(machineDeploymentGVR).Informer(),
+		machineSetInformer:        factory.ForResource(machineSetGVR).Informer(),
+		machineInformer:           factory.ForResource(machineGVR).Informer(),
+		stopCh:                    make(chan struct{}),
+		nodeGroups:                make(map[string]*NodeGroup),
+		machineSetOwner:           make(map[string]string),
+	}, nil
+}
+
+func (c *machineController) run() error {
+	c.informerFactory.Start(c.stopCh)
+	for gvr, ok := range c.informerFactory.WaitForCacheSync(c.stopCh) {
+		if !ok {
+			return fmt.Errorf("failed to sync informer cache for %v", gvr)
+		}
+	}
+	return nil
+}
+
+func (c *machineController) stop() error {
+	close(c.stopCh)
+	return nil
+}
+
+// nodeGroups rebuilds the set of autoscaler node groups from the current
+// informer caches: one NodeGroup per annotated MachineDeployment, plus one
+// per annotated MachineSet that isn't itself owned by a MachineDeployment
+// (e.g. a hand-rolled worker pool that skips the rolling-update owner).
+func (c *machineController) nodeGroups() ([]cloudprovider.NodeGroup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[string]bool)
+	machineSetOwner := make(map[string]string)
+	result := []cloudprovider.NodeGroup{}
+
+	deploymentIDs := make(map[string]string) // namespace/name -> node group id
+	for _, obj := range c.machineDeploymentInformer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		minSize, maxSize, ok := scalingBounds(u.GetAnnotations())
+		if !ok {
+			continue
+		}
+		ng := c.getOrCreateNodeGroup(u, minSize, maxSize, machineDeploymentGVR)
+		seen[ng.id] = true
+		deploymentIDs[u.GetNamespace()+"/"+u.GetName()] = ng.id
+		result = append(result, ng)
+	}
+
+	for _, obj := range c.machineSetInformer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		if owner := ownerReference(u, "MachineDeployment"); owner != "" {
+			if ngID, ok := deploymentIDs[u.GetNamespace()+"/"+owner]; ok {
+				machineSetOwner[u.GetNamespace()+"/"+u.GetName()] = ngID
+			}
+			// Scaling for this MachineSet flows through its
+			// MachineDeployment, so it never gets its own NodeGroup.
+			continue
+		}
+
+		minSize, maxSize, ok := scalingBounds(u.GetAnnotations())
+		if !ok {
+			continue
+		}
+		ng := c.getOrCreateNodeGroup(u, minSize, maxSize, machineSetGVR)
+		seen[ng.id] = true
+		machineSetOwner[u.GetNamespace()+"/"+u.GetName()] = ng.id
+		result = append(result, ng)
+	}
+
+	for id := range c.nodeGroups {
+		if !seen[id] {
+			delete(c.nodeGroups, id)
+		}
+	}
+	c.machineSetOwner = machineSetOwner
+
+	return result, nil
+}
+
+func (c *machineController) getOrCreateNodeGroup(u *unstructured.Unstructured, minSize, maxSize int, gvr schema.GroupVersionResource) *NodeGroup {
+	id := fmt.Sprintf("%s/%s/%s", gvr.Resource, u.GetNamespace(), u.GetName())
+	if ng, ok := c.nodeGroups[id]; ok {
+		ng.minSize = minSize
+		ng.maxSize = maxSize
+		return ng
+	}
+	ng := &NodeGroup{
+		id:         id,
+		namespace:  u.GetNamespace(),
+		name:       u.GetName(),
+		gvr:        gvr,
+		minSize:    minSize,
+		maxSize:    maxSize,
+		controller: c,
+	}
+	c.nodeGroups[id] = ng
+	return ng
+}
+
+// nodeGroupForNode finds the Machine owning node (via its provider ID) and
+// returns the NodeGroup responsible for scaling that Machine's MachineSet,
+// or nil if the node isn't managed by this provider.
+func (c *machineController) nodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if node.Spec.ProviderID == "" {
+		return nil, nil
+	}
+
+	for _, obj := range c.machineInformer.GetStore().List() {
+		m, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		providerID, _, _ := unstructured.NestedString(m.Object, "spec", "providerID")
+		if providerID != node.Spec.ProviderID {
+			continue
+		}
+
+		machineSetName := ownerReference(m, "MachineSet")
+		if machineSetName == "" {
+			return nil, nil
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		ngID, ok := c.machineSetOwner[m.GetNamespace()+"/"+machineSetName]
+		if !ok {
+			return nil, nil
+		}
+		return c.nodeGroups[ngID], nil
+	}
+
+	return nil, nil
+}
+
+// machinesForNodeGroup returns every Machine in the informer cache whose
+// MachineSet maps to ng, used by NodeGroup.Nodes()/DeleteNodes().
+func (c *machineController) machinesForNodeGroup(ng *NodeGroup) []*unstructured.Unstructured {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var machines []*unstructured.Unstructured
+	for _, obj := range c.machineInformer.GetStore().List() {
+		m, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		machineSetName := ownerReference(m, "MachineSet")
+		if machineSetName == "" {
+			continue
+		}
+		if c.machineSetOwner[m.GetNamespace()+"/"+machineSetName] == ng.id {
+			machines = append(machines, m)
+		}
+	}
+	return machines
+}
+
+func scalingBounds(annotations map[string]string) (min int, max int, ok bool) {
+	minStr, hasMin := annotations[minSizeAnnotation]
+	maxStr, hasMax := annotations[maxSizeAnnotation]
+	if !hasMin || !hasMax {
+		return 0, 0, false
+	}
+	min, err := strconv.Atoi(minStr)
+	if err != nil {
+		klog.Warningf("invalid %s annotation %q: %v", minSizeAnnotation, minStr, err)
+		return 0, 0, false
+	}
+	max, err = strconv.Atoi(maxStr)
+	if err != nil {
+		klog.Warningf("invalid %s annotation %q: %v", maxSizeAnnotation, maxStr, err)
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+func ownerReference(obj *unstructured.Unstructured, kind string) string {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == kind {
+			return owner.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package clusterapi
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestScalingBounds(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantOK      bool
+		wantMin     int
+		wantMax     int
+	}{
+		{name: "missing annotations", annotations: nil, wantOK: false},
+		{name: "missing max", annotations: map[string]string{minSizeAnnotation: "1"}, wantOK: false},
+		{name: "non-numeric min", annotations: map[string]string{minSizeAnnotation: "x", maxSizeAnnotation: "3"}, wantOK: false},
+		{name: "valid", annotations: map[string]string{minSizeAnnotation: "1", maxSizeAnnotation: "5"}, wantOK: true, wantMin: 1, wantMax: 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			min, max, ok := scalingBounds(tc.annotations)
+			if ok != tc.wantOK {
+				t.Fatalf("scalingBounds() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if ok && (min != tc.wantMin || max != tc.wantMax) {
+				t.Fatalf("scalingBounds() = (%d, %d), want (%d, %d)", min, max, tc.wantMin, tc.wantMax)
+			}
+		})
+	}
+}
+
+func TestOwnerReference(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	u.SetOwnerReferences([]metav1.OwnerReference{
+		{Kind: "MachineDeployment", Name: "workers"},
+	})
+
+	if got := ownerReference(u, "MachineDeployment"); got != "workers" {
+		t.Fatalf("ownerReference() = %q, want %q", got, "workers")
+	}
+	if got := ownerReference(u, "MachineSet"); got != "" {
+		t.Fatalf("ownerReference() = %q, want empty", got)
+	}
+}
+
+func TestInfraResourceFromKind(t *testing.T) {
+	if got := infraResourceFromKind("MAASMachineTemplate"); got != "maasmachinetemplates" {
+		t.Fatalf("infraResourceFromKind() = %q, want %q", got, "maasmachinetemplates")
+	}
+}
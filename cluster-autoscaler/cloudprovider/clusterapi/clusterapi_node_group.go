@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	ctx "context"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ cloudprovider.NodeGroup = (*NodeGroup)(nil)
+
+// NodeGroup implements cloudprovider.NodeGroup backed by either a
+// MachineDeployment or a MachineSet, identified by gvr.
+type NodeGroup struct {
+	id         string
+	namespace  string
+	name       string
+	gvr        schema.GroupVersionResource
+	minSize    int
+	maxSize    int
+	controller *machineController
+}
+
+// MaxSize returns maximum size of the node group.
+func (n *NodeGroup) MaxSize() int {
+	return n.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (n *NodeGroup) MinSize() int {
+	return n.minSize
+}
+
+// TargetSize returns the current target size of the node group, read from
+// the MachineDeployment/MachineSet's spec.replicas.
+func (n *NodeGroup) TargetSize() (int, error) {
+	u, err := n.get()
+	if err != nil {
+		return 0, err
+	}
+	replicas, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, nil
+	}
+	return int(replicas), nil
+}
+
+// IncreaseSize increases the size of the node group by patching
+// spec.replicas. The delta must be positive.
+func (n *NodeGroup) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive, got: %d", delta)
+	}
+	size, err := n.TargetSize()
+	if err != nil {
+		return err
+	}
+	newSize := size + delta
+	if newSize > n.maxSize {
+		return fmt.Errorf("size increase too large, desired: %d, max: %d", newSize, n.maxSize)
+	}
+	return n.setReplicas(newSize)
+}
+
+// DecreaseTargetSize decreases the target size of the node group. The delta
+// must be negative, and should only ever be used to reflect capacity that
+// has already been deleted (e.g. after a Machine was removed out of band),
+// not to trigger deletion itself.
+func (n *NodeGroup) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative, got: %d", delta)
+	}
+	size, err := n.TargetSize()
+	if err != nil {
+		return err
+	}
+	newSize := size + delta
+	if newSize < 0 {
+		return fmt.Errorf("size decrease too large, desired: %d", newSize)
+	}
+	return n.setReplicas(newSize)
+}
+
+// DeleteNodes marks each Machine backing nodes for deletion via the
+// cluster.x-k8s.io/delete-machine annotation and then decrements
+// spec.replicas by the number of nodes removed, so the owning controller
+// removes precisely those instances.
+func (n *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	machines := n.controller.machinesForNodeGroup(n)
+
+	deleted := 0
+	for _, node := range nodes {
+		machine := machineForProviderID(machines, node.Spec.ProviderID)
+		if machine == nil {
+			return fmt.Errorf("no Machine found for node %s (providerID %s) in node group %s", node.Name, node.Spec.ProviderID, n.id)
+		}
+		if err := n.annotateForDeletion(machine); err != nil {
+			return fmt.Errorf("failed to annotate machine %s/%s for deletion: %v", machine.GetNamespace(), machine.GetName(), err)
+		}
+		deleted++
+	}
+
+	if deleted == 0 {
+		return nil
+	}
+	return n.DecreaseTargetSize(-deleted)
+}
+
+// Id returns the node group identifier.
+func (n *NodeGroup) Id() string {
+	return n.id
+}
+
+// Debug returns a string containing all information regarding this node
+// group.
+func (n *NodeGroup) Debug() string {
+	size, _ := n.TargetSize()
+	return fmt.Sprintf("%s (min: %d, max: %d, target: %d)", n.id, n.minSize, n.maxSize, size)
+}
+
+// Nodes returns the instances belonging to this node group.
+func (n *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	machines := n.controller.machinesForNodeGroup(n)
+
+	instances := make([]cloudprovider.Instance, 0, len(machines))
+	for _, m := range machines {
+		providerID, _, _ := unstructured.NestedString(m.Object, "spec", "providerID")
+		if providerID == "" {
+			continue
+		}
+		instances = append(instances, cloudprovider.Instance{Id: providerID})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template for scale-up simulation, built
+// from the referenced InfrastructureMachineTemplate's labels, taints, and
+// instance-type-derived capacity.
+func (n *NodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, error) {
+	u, err := n.get()
+	if err != nil {
+		return nil, err
+	}
+
+	infraRef, found, err := unstructured.NestedMap(u.Object, "spec", "template", "spec", "infrastructureRef")
+	if err != nil || !found {
+		return nil, fmt.Errorf("node group %s has no infrastructureRef template to build a node from", n.id)
+	}
+
+	node, err := buildNodeFromInfrastructureRef(n.controller, n.namespace, infraRef, n.id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeInfo := schedulerframework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+func (n *NodeGroup) Exist() bool {
+	_, err := n.get()
+	return err == nil
+}
+
+// Create creates the node group on the cloud provider side. Not supported:
+// node groups in this provider always correspond to a pre-existing
+// MachineDeployment/MachineSet.
+func (n *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side. Not supported,
+// for the same reason as Create.
+func (n *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (n *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions returns NodeGroupAutoscalingOptions that should be used for
+// this particular NodeGroup. Using default options.
+func (n *NodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return &defaults, nil
+}
+
+func (n *NodeGroup) get() (*unstructured.Unstructured, error) {
+	return n.controller.dynamicClient.Resource(n.gvr).Namespace(n.namespace).Get(ctx.TODO(), n.name, metav1.GetOptions{})
+}
+
+func (n *NodeGroup) setReplicas(replicas int) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	_, err := n.controller.dynamicClient.Resource(n.gvr).Namespace(n.namespace).Patch(ctx.TODO(), n.name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func (n *NodeGroup) annotateForDeletion(machine *unstructured.Unstructured) error {
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, deleteMachineAnnotation))
+	_, err := n.controller.dynamicClient.Resource(machineGVR).Namespace(machine.GetNamespace()).Patch(ctx.TODO(), machine.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func machineForProviderID(machines []*unstructured.Unstructured, providerID string) *unstructured.Unstructured {
+	for _, m := range machines {
+		if id, _, _ := unstructured.NestedString(m.Object, "spec", "providerID"); id == providerID {
+			return m
+		}
+	}
+	return nil
+}
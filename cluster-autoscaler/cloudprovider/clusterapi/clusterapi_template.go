@@ -0,0 +1,116 @@
+package clusterapi
+
+import (
+	ctx "context"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// buildNodeFromInfrastructureRef resolves infraRef (the
+// spec.template.spec.infrastructureRef of a MachineDeployment/MachineSet,
+// i.e. an InfrastructureMachineTemplate reference) and synthesizes the
+// apiv1.Node a scale-up from nodeGroupID would create: labels copied from
+// the template's spec.template.metadata, taints from spec.template.spec.taints,
+// and capacity derived from the cpu/memory/gpu fields the infrastructure
+// provider exposes under spec.template.spec.capacity. No status block of
+// the template is read.
+func buildNodeFromInfrastructureRef(controller *machineController, namespace string, infraRef map[string]interface{}, nodeGroupID string) (*apiv1.Node, error) {
+	apiVersion, _, _ := unstructured.NestedString(infraRef, "apiVersion")
+	kind, _, _ := unstructured.NestedString(infraRef, "kind")
+	name, _, _ := unstructured.NestedString(infraRef, "name")
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("node group %s has an incomplete infrastructureRef", nodeGroupID)
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("node group %s has an invalid infrastructureRef apiVersion %q: %v", nodeGroupID, apiVersion, err)
+	}
+	gvr := gv.WithResource(infraResourceFromKind(kind))
+
+	tmpl, err := controller.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch InfrastructureMachineTemplate %s/%s for node group %s: %v", namespace, name, nodeGroupID, err)
+	}
+
+	labels, _, _ := unstructured.NestedStringMap(tmpl.Object, "spec", "template", "metadata", "labels")
+	taints := taintsFromTemplate(tmpl)
+	capacity := capacityFromTemplate(tmpl)
+
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("%s-template", nodeGroupID),
+			Labels: labels,
+		},
+		Status: apiv1.NodeStatus{
+			Capacity:    capacity,
+			Allocatable: capacity,
+			Phase:       apiv1.NodeRunning,
+		},
+		Spec: apiv1.NodeSpec{
+			Taints: taints,
+		},
+	}
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels["kubernetes.io/hostname"] = node.Name
+
+	return node, nil
+}
+
+func taintsFromTemplate(tmpl *unstructured.Unstructured) []apiv1.Taint {
+	raw, found, _ := unstructured.NestedSlice(tmpl.Object, "spec", "template", "spec", "taints")
+	if !found {
+		return nil
+	}
+	taints := make([]apiv1.Taint, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _, _ := unstructured.NestedString(m, "key")
+		value, _, _ := unstructured.NestedString(m, "value")
+		effect, _, _ := unstructured.NestedString(m, "effect")
+		taints = append(taints, apiv1.Taint{Key: key, Value: value, Effect: apiv1.TaintEffect(effect)})
+	}
+	return taints
+}
+
+// capacityFromTemplate reads the instance-type capacity an infrastructure
+// provider exposes on its machine template, e.g. spec.template.spec.capacity
+// holding cpu/memory/ephemeral-storage/gpu quantities as strings.
+func capacityFromTemplate(tmpl *unstructured.Unstructured) apiv1.ResourceList {
+	raw, found, _ := unstructured.NestedStringMap(tmpl.Object, "spec", "template", "spec", "capacity")
+	capacity := apiv1.ResourceList{}
+	if !found {
+		return capacity
+	}
+	for name, qty := range raw {
+		q, err := resource.ParseQuantity(qty)
+		if err != nil {
+			continue
+		}
+		capacity[apiv1.ResourceName(name)] = q
+	}
+	return capacity
+}
+
+// infraResourceFromKind lower-cases and pluralizes an InfrastructureMachine
+// template Kind into its API resource name, e.g. "MAASMachineTemplate" ->
+// "maasmachinetemplates".
+func infraResourceFromKind(kind string) string {
+	lower := []rune(kind)
+	for i, r := range lower {
+		if r >= 'A' && r <= 'Z' {
+			lower[i] = r + ('a' - 'A')
+		}
+	}
+	return string(lower) + "s"
+}
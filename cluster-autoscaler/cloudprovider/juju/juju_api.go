@@ -2,38 +2,181 @@ package juju
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/api/client/action"
+	"github.com/juju/juju/api/client/annotations"
 	"github.com/juju/juju/api/client/application"
 	apiclient "github.com/juju/juju/api/client/client"
 	"github.com/juju/juju/api/connector"
+	"github.com/juju/juju/core/constraints"
 	"github.com/juju/juju/rpc/params"
+	"github.com/juju/names/v4"
 )
 
+// actionPollInterval is how often WaitForAction re-checks an action's status.
+const actionPollInterval = 2 * time.Second
+
 type JujuAPI struct {
+	conn              api.Connection      // conn is the underlying connection, closed by Close
 	applicationClient *application.Client // applicationClient is limited to application API calls
 	statusClient      *apiclient.Client   // statusClient is used to gather status information
+	annotationsClient *annotations.Client // annotationsClient is used to read application annotations for auto-discovery
+	actionClient      *action.Client      // actionClient is used to run charm actions such as pause
+	retry             RetryStrategy       // retry governs retries of applicationClient/statusClient calls
 }
 
-func NewJujuAPi(connector *connector.SimpleConnector) (*JujuAPI, error) {
+// NewJujuAPi builds a JujuAPI over an already-configured connector, which may
+// dial a controller directly (connector.SimpleConnector) or reuse a local
+// `juju login` session (connector.ClientStoreConnector). Transient errors
+// from applicationClient/statusClient calls are retried according to retry.
+func NewJujuAPi(connector connector.Connector, retry RetryStrategy) (*JujuAPI, error) {
 	conn, err := connector.Connect()
 	if err != nil {
 		return nil, fmt.Errorf("error connecting using Juju SimpleConnector: %v", err)
 	}
 
 	jujuAPI := new(JujuAPI)
+	jujuAPI.conn = conn
 	jujuAPI.applicationClient = application.NewClient(conn)
 	jujuAPI.statusClient = apiclient.NewClient(conn)
+	jujuAPI.annotationsClient = annotations.NewClient(conn)
+	jujuAPI.actionClient = action.NewClient(conn)
+	jujuAPI.retry = retry
 	return jujuAPI, nil
 }
 
+// Close closes the underlying connection to the Juju controller.
+func (jujuAPI *JujuAPI) Close() error {
+	return jujuAPI.conn.Close()
+}
+
 func (jujuAPI *JujuAPI) AddUnits(args application.AddUnitsParams) ([]string, error) {
-	return jujuAPI.applicationClient.AddUnits(args)
+	var result []string
+	err := jujuAPI.retry.call(func() error {
+		var err error
+		result, err = jujuAPI.applicationClient.AddUnits(args)
+		return err
+	})
+	return result, err
 }
 
 func (jujuAPI *JujuAPI) DestroyUnits(args application.DestroyUnitsParams) ([]params.DestroyUnitResult, error) {
-	return jujuAPI.applicationClient.DestroyUnits(args)
+	var result []params.DestroyUnitResult
+	err := jujuAPI.retry.call(func() error {
+		var err error
+		result, err = jujuAPI.applicationClient.DestroyUnits(args)
+		return err
+	})
+	return result, err
 }
 
 func (jujuAPI *JujuAPI) Status(patterns []string) (*params.FullStatus, error) {
-	return jujuAPI.statusClient.Status(patterns)
+	var result *params.FullStatus
+	err := jujuAPI.retry.call(func() error {
+		var err error
+		result, err = jujuAPI.statusClient.Status(patterns)
+		return err
+	})
+	return result, err
+}
+
+// ApplicationAnnotations returns the annotations set on each named
+// application, keyed by application name. Applications with no annotations
+// set are omitted from the result.
+func (jujuAPI *JujuAPI) ApplicationAnnotations(applicationNames []string) (map[string]map[string]string, error) {
+	tags := make([]string, len(applicationNames))
+	for i, name := range applicationNames {
+		tags[i] = names.NewApplicationTag(name).String()
+	}
+
+	results, err := jujuAPI.annotationsClient.Get(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	annotationsByApp := make(map[string]map[string]string, len(results))
+	for _, result := range results {
+		if result.Error.Error != nil {
+			continue
+		}
+		tag, err := names.ParseApplicationTag(result.EntityTag)
+		if err != nil {
+			continue
+		}
+		annotationsByApp[tag.Id()] = result.Annotations
+	}
+	return annotationsByApp, nil
+}
+
+// ApplicationConstraints returns the machine constraints (cores, mem,
+// root-disk, instance-type, tags) set on applicationName, used to price its
+// node group and detect whether it's a GPU workload.
+func (jujuAPI *JujuAPI) ApplicationConstraints(applicationName string) (constraints.Value, error) {
+	var result constraints.Value
+	err := jujuAPI.retry.call(func() error {
+		info, err := jujuAPI.applicationClient.Get("", applicationName)
+		if err != nil {
+			return err
+		}
+		result = info.Constraints
+		return nil
+	})
+	return result, err
+}
+
+// SetConstraints sets applicationName's machine constraints, so that
+// subsequently added units are provisioned against cons.
+func (jujuAPI *JujuAPI) SetConstraints(applicationName string, cons constraints.Value) error {
+	return jujuAPI.retry.call(func() error {
+		return jujuAPI.applicationClient.SetConstraints(applicationName, cons)
+	})
+}
+
+// ApplicationCharmActions returns the actions applicationName's charm
+// exposes, keyed by action name.
+func (jujuAPI *JujuAPI) ApplicationCharmActions(applicationName string) (map[string]action.ActionSpec, error) {
+	return jujuAPI.actionClient.ApplicationCharmActions(applicationName)
+}
+
+// RunAction enqueues actionName on unitName and returns the action's ID so
+// the caller can poll for completion with WaitForAction.
+func (jujuAPI *JujuAPI) RunAction(unitName string, actionName string) (string, error) {
+	results, err := jujuAPI.actionClient.Enqueue([]action.Action{{
+		Receiver: names.NewUnitTag(unitName).String(),
+		Name:     actionName,
+	}})
+	if err != nil {
+		return "", err
+	}
+	if len(results) != 1 {
+		return "", fmt.Errorf("expected 1 result enqueuing action %s on %s, got %d", actionName, unitName, len(results))
+	}
+	if results[0].Error != nil {
+		return "", results[0].Error
+	}
+	return results[0].Action.ID, nil
+}
+
+// WaitForAction polls actionID until it reaches a terminal status or timeout
+// elapses, returning the terminal status (e.g. "completed", "failed").
+func (jujuAPI *JujuAPI) WaitForAction(actionID string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		results, err := jujuAPI.actionClient.Actions([]string{actionID})
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 1 {
+			switch results[0].Status {
+			case "completed", "failed", "cancelled", "error":
+				return results[0].Status, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for action %s to finish", actionID)
+		}
+		time.Sleep(actionPollInterval)
+	}
 }
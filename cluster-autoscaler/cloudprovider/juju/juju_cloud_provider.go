@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/juju/juju/api/connector"
 	"gopkg.in/yaml.v2"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/topology"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -41,23 +43,90 @@ import (
 var _ cloudprovider.CloudProvider = (*jujuCloudProvider)(nil)
 
 const (
-	GPULabel             = "juju/gpu-node" // GPULabel is the label added to nodes with GPU resource.
+	GPULabel = "juju/gpu-node" // GPULabel is the label holding a GPU node's GPU type.
+	// nodeGroupIDLabel lets the pricing model identify a node's node group
+	// even for theoretical template nodes, which never have a ProviderID.
+	nodeGroupIDLabel     = "juju/node-group-id"
 	scaleToZeroSupported = true
 )
 
+var (
+	drainGracePeriod = flag.Duration("juju-drain-grace-period", 90*time.Second,
+		"grace period given to each evicted pod when scaling down a Juju unit")
+	drainTimeout = flag.Duration("juju-drain-timeout", 5*time.Minute,
+		"how long to wait for the pause action and pod eviction to finish before destroying a Juju unit")
+	forceDestroyAfter = flag.Duration("juju-force-destroy-after", 10*time.Minute,
+		"how long to wait for a gracefully destroyed Juju unit to actually disappear before retrying with Force")
+
+	destroyStorage = flag.Bool("juju-destroy-storage", false,
+		"destroy a unit's attached storage instead of detaching it when scaling down")
+	forceDestroy = flag.Bool("juju-force-destroy", true,
+		"retry a stuck unit removal with Force: true after juju-force-destroy-after elapses")
+	forceDestroyMaxWait = flag.Duration("juju-force-destroy-max-wait", time.Minute,
+		"how long Juju waits between each step of a forced unit removal")
+
+	stateConfigMapNamespace = flag.String("juju-state-configmap-namespace", "kube-system",
+		"namespace of the ConfigMap used to persist in-flight Juju unit provisioning state across restarts")
+	stateConfigMapName = flag.String("juju-state-configmap-name", "cluster-autoscaler-juju-state",
+		"name of the ConfigMap used to persist in-flight Juju unit provisioning state across restarts")
+	stateEntryTTL = flag.Duration("juju-state-entry-ttl", 15*time.Minute,
+		"how long a persisted Juju unit state entry is trusted before it's considered abandoned and dropped")
+)
+
 // Note: struct fields must be public in order for unmarshal to
 // correctly populate the data.
 type jujuCloudConfig struct {
+	// Controllers lists every Juju controller node groups may target. Node
+	// group specs take the form <controller>:<model>:<application>, naming
+	// one of these entries by its Name, which lets a single autoscaler drive
+	// node groups spread across several controllers (e.g. one per region).
+	Controllers []jujuControllerConfig `yaml:"controllers"`
+
+	// Pricing maps a node group's machine constraints to an hourly cost, so
+	// the autoscaler's expander can make cost-aware scale-up decisions.
+	Pricing []pricingEntry `yaml:"pricing"`
+}
+
+// jujuControllerConfig is one entry of jujuCloudConfig.Controllers.
+type jujuControllerConfig struct {
+	// Name identifies this controller in a node group spec's
+	// <controller>:<model>:<application> name.
+	Name string `yaml:"name"`
+
+	// Auth selects how to authenticate to this controller: "password" (the
+	// default) dials Endpoints directly with User/Password/CAcert,
+	// "client-store" reuses the local `juju login` session named by
+	// ControllerName instead of storing a plaintext password.
+	Auth string `yaml:"auth"`
+
+	Endpoints []string `yaml:"endpoints"`
 	User      string   `yaml:"user"`
 	Password  string   `yaml:"password"`
-	Endpoints []string `yaml:"endpoints"`
 	CAcert    string   `yaml:"ca-cert"`
+
+	// ControllerName is the client-store controller name to dial when Auth
+	// is "client-store". Defaults to Name.
+	ControllerName string `yaml:"controller-name"`
+
+	// Models, when non-empty, enables auto-discovery for this controller: on
+	// every Refresh the provider lists applications in each model and
+	// manages a node group for any application annotated with
+	// autoscalerEnabledAnnotation. Each such Refresh replaces the provider's
+	// node groups with the discovered set, so groups built from the static
+	// --nodes specs are no longer returned once discovery is enabled.
+	Models []string `yaml:"models"`
 }
 
 // jujuCloudProvider implements CloudProvider interface.
 type jujuCloudProvider struct {
 	resourceLimiter *cloudprovider.ResourceLimiter
 	nodeGroups      []cloudprovider.NodeGroup
+	kubeClient      kube_client.Interface
+	jujuConfig      jujuCloudConfig
+	discovery       *discoverer
+
+	// topologyStore backs FitsTopology; see juju_topology.go.
+	topologyStore *topology.Store
 }
 
 func newJujuCloudProvider(rl *cloudprovider.ResourceLimiter, nodeGroups []cloudprovider.NodeGroup) (*jujuCloudProvider, error) {
@@ -98,7 +167,7 @@ func (j *jujuCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.No
 // Pricing returns pricing model for this cloud provider or error if not
 // available. Implementation optional.
 func (j *jujuCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
-	return nil, cloudprovider.ErrNotImplemented
+	return &jujuPricingModel{provider: j}, nil
 }
 
 // GetAvailableMachineTypes get all machine types that can be requested from
@@ -134,12 +203,27 @@ func (j *jujuCloudProvider) GPULabel() string {
 
 // GetAvailableGPUTypes return all available GPU types cloud provider supports.
 func (j *jujuCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
-	return nil
+	types := make(map[string]struct{})
+	for _, ng := range j.nodeGroups {
+		jujuNG, ok := ng.(*NodeGroup)
+		if !ok || jujuNG.gpuType == "" {
+			continue
+		}
+		types[jujuNG.gpuType] = struct{}{}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return types
 }
 
 // Cleanup cleans up open resources before the cloud provider is destroyed,
-// i.e. go routines etc.
+// i.e. go routines etc. In particular, it closes every Juju API connection
+// discovery has cached, since nothing else ever tears them down.
 func (j *jujuCloudProvider) Cleanup() error {
+	if j.discovery != nil {
+		j.discovery.close()
+	}
 	return nil
 }
 
@@ -154,13 +238,23 @@ func (j *jujuCloudProvider) Refresh() error {
 	// The loop below calls the refresh function for each node group (which updates state to include any externally added or removed nodes),
 	// and updates the target size to match the current size of the node group
 	klog.Infof("refreshing node groups")
+
+	if j.discovery != nil {
+		discovered, err := j.discovery.discover()
+		if err != nil {
+			klog.Errorf("error auto-discovering Juju node groups: %v", err)
+		} else {
+			j.nodeGroups = discovered
+		}
+	}
+
 	for _, node := range j.nodeGroups {
 		// Cast the cloudprovider.NodeGroup interface to the underlying juju NodeGroup struct
 		jujuNodeGroup, ok := node.(*NodeGroup)
 		if ok {
 			klog.Infof("updating node group %s target", jujuNodeGroup.id)
 			jujuNodeGroup.manager.refresh()
-			jujuNodeGroup.target = len(jujuNodeGroup.manager.units)
+			jujuNodeGroup.target = len(jujuNodeGroup.manager.Units(jujuNodeGroup.application))
 		}
 	}
 
@@ -191,6 +285,19 @@ func BuildJuju(
 		klog.Fatalf("Couldn't read cloud provider configuration yaml file %s", err)
 	}
 
+	drain := DrainOptions{
+		GracePeriod: *drainGracePeriod,
+		Timeout:     *drainTimeout,
+		ForceAfter:  *forceDestroyAfter,
+	}
+	destroy := DestroyPolicy{
+		DestroyStorage: *destroyStorage,
+		Force:          *forceDestroy,
+		MaxWait:        *forceDestroyMaxWait,
+	}
+
+	store := newStateStore(kubeClient, *stateConfigMapNamespace, *stateConfigMapName, *stateEntryTTL)
+
 	ngs := []cloudprovider.NodeGroup{}
 	for _, nodeGroupSpecString := range do.NodeGroupSpecs {
 		nodeGroupSpec, err := dynamic.SpecFromString(nodeGroupSpecString, scaleToZeroSupported)
@@ -198,45 +305,22 @@ func BuildJuju(
 			klog.Fatalf("failed to parse node group spec: %v", err)
 			continue
 		}
-		model, application, err := parseNodeGroupName(nodeGroupSpec.Name)
+		controllerName, model, application, err := parseNodeGroupName(nodeGroupSpec.Name)
 		if err != nil {
 			klog.Fatalf("failed to parse node group name: %v", err)
 			continue
 		}
-
-		connector, err := connector.NewSimple(connector.SimpleConfig{
-			ControllerAddresses: jujuConfig.Endpoints,
-			CACert:              jujuConfig.CAcert,
-			ModelUUID:           model,
-			Username:            jujuConfig.User,
-			Password:            jujuConfig.Password,
-		})
-
-		if err != nil {
-			klog.Fatalf("failed to create simple connector %v", err)
-			continue
-		}
-
-		jujuAPI, err := NewJujuAPi(connector)
+		ctrl, err := findController(jujuConfig, controllerName)
 		if err != nil {
-			klog.Fatalf("failed to create JujuClient %v", err)
+			klog.Fatalf("failed to build node group %s: %v", nodeGroupSpec.Name, err)
 			continue
 		}
 
-		man, err := NewManager(jujuAPI, kubeClient, model, application)
+		ng, err := newJujuNodeGroup(ctrl, kubeClient, model, application, nodeGroupSpec.MinSize, nodeGroupSpec.MaxSize, drain, destroy, store, jujuConfig.Pricing, "", nil)
 		if err != nil {
-			klog.Fatalf("error creating manager: %v", err)
+			klog.Fatalf("failed to create node group for %s:%s:%s: %v", controllerName, model, application, err)
 			continue
 		}
-
-		jujuID := fmt.Sprintf("juju-%s-%s", model, application)
-		ng := &NodeGroup{
-			id:      jujuID,
-			minSize: nodeGroupSpec.MinSize,
-			maxSize: nodeGroupSpec.MaxSize,
-			target:  len(man.units),
-			manager: man,
-		}
 		ngs = append(ngs, ng)
 	}
 
@@ -244,18 +328,106 @@ func BuildJuju(
 	if err != nil {
 		klog.Fatalf("Failed to create Juju cloud provider: %v", err)
 	}
+	provider.kubeClient = kubeClient
+	provider.jujuConfig = jujuConfig
+
+	for _, ctrl := range jujuConfig.Controllers {
+		if len(ctrl.Models) > 0 {
+			provider.discovery = newDiscoverer(jujuConfig.Controllers, kubeClient, drain, destroy, store, jujuConfig.Pricing)
+			break
+		}
+	}
 
 	return provider
 }
 
-func parseNodeGroupName(name string) (string, string, error) {
+// dialController opens a connector.Connector for ctrl, using either a
+// directly-dialed password connection or, when ctrl.Auth is "client-store",
+// the local `juju login` session named by ctrl.ControllerName.
+func dialController(ctrl jujuControllerConfig, model string) (connector.Connector, error) {
+	switch ctrl.Auth {
+	case "client-store":
+		controllerName := ctrl.ControllerName
+		if controllerName == "" {
+			controllerName = ctrl.Name
+		}
+		return connector.NewClientStore(connector.ClientStoreConfig{
+			ControllerName: controllerName,
+			ModelUUID:      model,
+		})
+	case "", "password":
+		return connector.NewSimple(connector.SimpleConfig{
+			ControllerAddresses: ctrl.Endpoints,
+			CACert:              ctrl.CAcert,
+			ModelUUID:           model,
+			Username:            ctrl.User,
+			Password:            ctrl.Password,
+		})
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q for controller %s", ctrl.Auth, ctrl.Name)
+	}
+}
+
+// newJujuAPIForController dials model on ctrl.
+func newJujuAPIForController(ctrl jujuControllerConfig, model string) (*JujuAPI, error) {
+	conn, err := dialController(ctrl, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connector for controller %s: %v", ctrl.Name, err)
+	}
+
+	return NewJujuAPi(conn, DefaultRetryStrategy())
+}
+
+// newJujuNodeGroup connects to model on ctrl and builds the NodeGroup +
+// Manager pair backing the given application, pricing it from pricing,
+// detecting its GPU type from its Juju machine constraints, setting its
+// machine constraints/placement from cons/placement if given, and destroying
+// its units according to destroy when scaling down.
+func newJujuNodeGroup(ctrl jujuControllerConfig, kubeClient kube_client.Interface, model, application string, minSize, maxSize int, drain DrainOptions, destroy DestroyPolicy, store *stateStore, pricing []pricingEntry, cons string, placement []string) (*NodeGroup, error) {
+	jujuAPI, err := newJujuAPIForController(ctrl, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JujuClient: %v", err)
+	}
+
+	id := fmt.Sprintf("juju-%s-%s-%s", ctrl.Name, model, application)
+
+	man := NewManager(jujuAPI, kubeClient, model, drain, store)
+	if err := man.AddApplication(application, minSize, maxSize, id, cons, placement, destroy); err != nil {
+		return nil, fmt.Errorf("error creating manager: %v", err)
+	}
+
+	gpuType, _ := gpuTypeFromTags(man.Constraints(application))
+
+	return &NodeGroup{
+		id:          id,
+		application: application,
+		minSize:     minSize,
+		maxSize:     maxSize,
+		target:      len(man.Units(application)),
+		manager:     man,
+		hourlyCost:  hourlyCostFor(pricing, man.Constraints(application)),
+		gpuType:     gpuType,
+	}, nil
+}
+
+// findController returns the named entry from cfg.Controllers.
+func findController(cfg jujuCloudConfig, name string) (jujuControllerConfig, error) {
+	for _, ctrl := range cfg.Controllers {
+		if ctrl.Name == name {
+			return ctrl, nil
+		}
+	}
+	return jujuControllerConfig{}, fmt.Errorf("no controller named %q configured", name)
+}
+
+// parseNodeGroupName splits a <controller>:<model>:<application> node group
+// name into its parts.
+func parseNodeGroupName(name string) (controller string, model string, application string, err error) {
 	s := strings.Split(name, ":")
-	if len(s) != 2 {
-		return "", "", fmt.Errorf("failed to parse node group name: %s, expected <model>:<application>", name)
+	if len(s) != 3 {
+		return "", "", "", fmt.Errorf("failed to parse node group name: %s, expected <controller>:<model>:<application>", name)
 	}
-	model := s[0]
-	application := s[1]
-	return model, application, nil
+	return s[0], s[1], s[2], nil
 }
 
 func readCloudConfigYaml(configRC io.ReadCloser) (jujuCloudConfig, error) {
@@ -0,0 +1,248 @@
+package juju
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/juju/juju/rpc/params"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// autoscalerEnabledAnnotation marks a Juju application as managed by
+	// the autoscaler when auto-discovery is enabled.
+	autoscalerEnabledAnnotation = "autoscaler.k8s.io/enabled"
+	// autoscalerMinAnnotation and autoscalerMaxAnnotation carry the node
+	// group's min/max size, mirroring the --nodes spec fields.
+	autoscalerMinAnnotation = "autoscaler.k8s.io/min"
+	autoscalerMaxAnnotation = "autoscaler.k8s.io/max"
+
+	// autoscalerConstraintsAnnotation carries a Juju constraints string
+	// (e.g. "mem=8G cores=4 tags=gpu=nvidia") applied to the application
+	// so scaled-up units are provisioned against it.
+	autoscalerConstraintsAnnotation = "autoscaler.k8s.io/constraints"
+
+	// autoscalerPlacementAnnotation carries a comma-separated list of
+	// Juju placement directives (e.g. "zone=us-east-1a") new units are
+	// created with.
+	autoscalerPlacementAnnotation = "autoscaler.k8s.io/placement"
+)
+
+// discoveryClient is the subset of JujuAPI the discoverer needs, split out
+// so tests can supply a fake without dialing a real Juju controller.
+type discoveryClient interface {
+	Status(patterns []string) (*params.FullStatus, error)
+	ApplicationAnnotations(applicationNames []string) (map[string]map[string]string, error)
+	Close() error
+}
+
+// discoverer auto-discovers autoscaler-managed applications across every
+// model of every configured Juju controller on each Refresh, so operators
+// can add/remove autoscaled applications from a running cluster without
+// restarting the autoscaler.
+type discoverer struct {
+	controllers []jujuControllerConfig
+	kubeClient  kube_client.Interface
+	drain       DrainOptions
+	destroy     DestroyPolicy
+	store       *stateStore
+	pricing     []pricingEntry
+
+	// dial creates the client used to talk to a given controller+model, and
+	// newNodeGroup builds the NodeGroup+Manager for a newly discovered
+	// application. Both are overridden in tests to avoid dialing a real
+	// Juju controller.
+	dial         func(jujuControllerConfig, string) (discoveryClient, error)
+	newNodeGroup func(jujuControllerConfig, kube_client.Interface, string, string, int, int, DrainOptions, DestroyPolicy, *stateStore, []pricingEntry, string, []string) (*NodeGroup, error)
+
+	mu   sync.Mutex
+	apis map[string]discoveryClient // "controller:model" -> connection, cached across refreshes
+	ngs  map[string]*NodeGroup      // "controller:model:application" -> node group, cached so refresh is idempotent
+}
+
+func newDiscoverer(controllers []jujuControllerConfig, kubeClient kube_client.Interface, drain DrainOptions, destroy DestroyPolicy, store *stateStore, pricing []pricingEntry) *discoverer {
+	return &discoverer{
+		controllers: controllers,
+		kubeClient:  kubeClient,
+		drain:       drain,
+		destroy:     destroy,
+		store:       store,
+		pricing:     pricing,
+		dial: func(ctrl jujuControllerConfig, model string) (discoveryClient, error) {
+			return newJujuAPIForController(ctrl, model)
+		},
+		newNodeGroup: newJujuNodeGroup,
+		apis:         make(map[string]discoveryClient),
+		ngs:          make(map[string]*NodeGroup),
+	}
+}
+
+// discover returns the current set of autoscaler-managed node groups across
+// every configured controller and model, creating a NodeGroup for any
+// newly-annotated application and dropping any that are no longer found or
+// are no longer annotated as enabled.
+func (d *discoverer) discover() ([]cloudprovider.NodeGroup, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	found := make(map[string]bool)
+	result := []cloudprovider.NodeGroup{}
+
+	for _, ctrl := range d.controllers {
+		for _, model := range ctrl.Models {
+			jujuAPI, err := d.apiForController(ctrl, model)
+			if err != nil {
+				klog.Errorf("error connecting to %s:%s for auto-discovery: %v", ctrl.Name, model, err)
+				continue
+			}
+
+			status, err := jujuAPI.Status(nil)
+			if err != nil {
+				klog.Errorf("error getting status for %s:%s: %v", ctrl.Name, model, err)
+				continue
+			}
+
+			appNames := make([]string, 0, len(status.Applications))
+			for name := range status.Applications {
+				appNames = append(appNames, name)
+			}
+
+			annotationsByApp, err := jujuAPI.ApplicationAnnotations(appNames)
+			if err != nil {
+				klog.Errorf("error getting application annotations for %s:%s: %v", ctrl.Name, model, err)
+				continue
+			}
+
+			for appName := range status.Applications {
+				minSize, maxSize, ok := scalingBounds(annotationsByApp[appName])
+				if !ok {
+					continue
+				}
+
+				key := ctrl.Name + ":" + model + ":" + appName
+				found[key] = true
+
+				if ng, ok := d.ngs[key]; ok {
+					ng.minSize = minSize
+					ng.maxSize = maxSize
+					result = append(result, ng)
+					continue
+				}
+
+				cons := annotationsByApp[appName][autoscalerConstraintsAnnotation]
+				placement := splitPlacement(annotationsByApp[appName][autoscalerPlacementAnnotation])
+				ng, err := d.newNodeGroup(ctrl, d.kubeClient, model, appName, minSize, maxSize, d.drain, d.destroy, d.store, d.pricing, cons, placement)
+				if err != nil {
+					klog.Errorf("error creating node group for newly discovered application %s: %v", key, err)
+					continue
+				}
+				klog.Infof("auto-discovered new autoscaler-managed application %s", key)
+				d.ngs[key] = ng
+				result = append(result, ng)
+			}
+		}
+	}
+
+	for key := range d.ngs {
+		if !found[key] {
+			klog.Infof("application %s is no longer enabled for autoscaling, removing its node group", key)
+			delete(d.ngs, key)
+		}
+	}
+
+	d.closeUnusedAPIs()
+
+	return result, nil
+}
+
+// closeUnusedAPIs closes and drops any cached connection in d.apis that no
+// surviving entry in d.ngs still references, e.g. once the last
+// autoscaler-managed application of a controller+model is removed. Must be
+// called with d.mu held.
+func (d *discoverer) closeUnusedAPIs() {
+	stillUsed := make(map[string]bool, len(d.ngs))
+	for key, ng := range d.ngs {
+		stillUsed[strings.TrimSuffix(key, ":"+ng.application)] = true
+	}
+	for key, jujuAPI := range d.apis {
+		if stillUsed[key] {
+			continue
+		}
+		if err := jujuAPI.Close(); err != nil {
+			klog.Warningf("error closing Juju connection for %s: %v", key, err)
+		}
+		delete(d.apis, key)
+	}
+}
+
+// close closes every cached Juju API connection, regardless of whether its
+// applications are still enabled for autoscaling, e.g. when the cloud
+// provider itself is being torn down.
+func (d *discoverer) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, jujuAPI := range d.apis {
+		if err := jujuAPI.Close(); err != nil {
+			klog.Warningf("error closing Juju connection for %s: %v", key, err)
+		}
+		delete(d.apis, key)
+	}
+}
+
+func (d *discoverer) apiForController(ctrl jujuControllerConfig, model string) (discoveryClient, error) {
+	key := ctrl.Name + ":" + model
+	if jujuAPI, ok := d.apis[key]; ok {
+		return jujuAPI, nil
+	}
+	jujuAPI, err := d.dial(ctrl, model)
+	if err != nil {
+		return nil, err
+	}
+	d.apis[key] = jujuAPI
+	return jujuAPI, nil
+}
+
+// scalingBounds parses the autoscaler min/max annotations, returning
+// ok=false when the application isn't marked enabled or the bounds are
+// missing/invalid.
+func scalingBounds(annotations map[string]string) (min int, max int, ok bool) {
+	if annotations[autoscalerEnabledAnnotation] != "true" {
+		return 0, 0, false
+	}
+
+	minStr, hasMin := annotations[autoscalerMinAnnotation]
+	maxStr, hasMax := annotations[autoscalerMaxAnnotation]
+	if !hasMin || !hasMax {
+		return 0, 0, false
+	}
+
+	min, err := strconv.Atoi(minStr)
+	if err != nil {
+		klog.Warningf("invalid %s annotation %q: %v", autoscalerMinAnnotation, minStr, err)
+		return 0, 0, false
+	}
+	max, err = strconv.Atoi(maxStr)
+	if err != nil {
+		klog.Warningf("invalid %s annotation %q: %v", autoscalerMaxAnnotation, maxStr, err)
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// splitPlacement splits a comma-separated placement annotation value into
+// its individual directives, dropping empty entries.
+func splitPlacement(annotation string) []string {
+	if annotation == "" {
+		return nil
+	}
+	var placement []string
+	for _, directive := range strings.Split(annotation, ",") {
+		if directive = strings.TrimSpace(directive); directive != "" {
+			placement = append(placement, directive)
+		}
+	}
+	return placement
+}
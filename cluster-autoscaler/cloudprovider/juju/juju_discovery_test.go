@@ -0,0 +1,225 @@
+package juju
+
+import (
+	"testing"
+
+	"github.com/juju/juju/rpc/params"
+	kube_client "k8s.io/client-go/kubernetes"
+)
+
+type fakeDiscoveryClient struct {
+	status      *params.FullStatus
+	annotations map[string]map[string]string
+	closed      bool
+}
+
+func (f *fakeDiscoveryClient) Status(patterns []string) (*params.FullStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeDiscoveryClient) ApplicationAnnotations(applicationNames []string) (map[string]map[string]string, error) {
+	return f.annotations, nil
+}
+
+func (f *fakeDiscoveryClient) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestDiscoverCreatesNodeGroupForEnabledApplication(t *testing.T) {
+	client := &fakeDiscoveryClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {},
+				"ignored": {},
+			},
+		},
+		annotations: map[string]map[string]string{
+			"workers": {
+				autoscalerEnabledAnnotation: "true",
+				autoscalerMinAnnotation:     "1",
+				autoscalerMaxAnnotation:     "5",
+			},
+		},
+	}
+
+	var created []string
+	d := newDiscoverer([]jujuControllerConfig{{Name: "controller-1", Models: []string{"model-1"}}}, nil, DrainOptions{}, DestroyPolicy{}, nil, nil)
+	d.dial = func(jujuControllerConfig, string) (discoveryClient, error) { return client, nil }
+	d.newNodeGroup = func(ctrl jujuControllerConfig, _ kube_client.Interface, model, application string, minSize, maxSize int, _ DrainOptions, _ DestroyPolicy, _ *stateStore, _ []pricingEntry, _ string, _ []string) (*NodeGroup, error) {
+		created = append(created, ctrl.Name+":"+model+":"+application)
+		return &NodeGroup{id: "juju-" + model + "-" + application, application: application, minSize: minSize, maxSize: maxSize, manager: &Manager{apps: map[string]*applicationState{application: {units: map[string]*Unit{}}}}}, nil
+	}
+
+	groups, err := d.discover()
+	if err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected exactly one discovered node group, got %d", len(groups))
+	}
+	if len(created) != 1 || created[0] != "controller-1:model-1:workers" {
+		t.Fatalf("expected a node group to be created for model-1:workers, got %v", created)
+	}
+
+	// A second discover() with the same state must not create it again.
+	groups, err = d.discover()
+	if err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if len(groups) != 1 || len(created) != 1 {
+		t.Fatalf("expected discover() to be idempotent, got %d groups and %d creations", len(groups), len(created))
+	}
+}
+
+func TestDiscoverRemovesNodeGroupWhenNoLongerEnabled(t *testing.T) {
+	client := &fakeDiscoveryClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{"workers": {}},
+		},
+		annotations: map[string]map[string]string{
+			"workers": {
+				autoscalerEnabledAnnotation: "true",
+				autoscalerMinAnnotation:     "1",
+				autoscalerMaxAnnotation:     "5",
+			},
+		},
+	}
+
+	d := newDiscoverer([]jujuControllerConfig{{Name: "controller-1", Models: []string{"model-1"}}}, nil, DrainOptions{}, DestroyPolicy{}, nil, nil)
+	d.dial = func(jujuControllerConfig, string) (discoveryClient, error) { return client, nil }
+	d.newNodeGroup = func(_ jujuControllerConfig, _ kube_client.Interface, model, application string, minSize, maxSize int, _ DrainOptions, _ DestroyPolicy, _ *stateStore, _ []pricingEntry, _ string, _ []string) (*NodeGroup, error) {
+		return &NodeGroup{id: "juju-" + model + "-" + application, application: application, minSize: minSize, maxSize: maxSize, manager: &Manager{apps: map[string]*applicationState{application: {units: map[string]*Unit{}}}}}, nil
+	}
+
+	if _, err := d.discover(); err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+
+	client.annotations = map[string]map[string]string{}
+	groups, err := d.discover()
+	if err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Fatalf("expected node group to be removed once no longer annotated, got %d groups", len(groups))
+	}
+}
+
+func TestDiscoverClosesConnectionOnceLastApplicationRemoved(t *testing.T) {
+	client := &fakeDiscoveryClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{"workers": {}},
+		},
+		annotations: map[string]map[string]string{
+			"workers": {
+				autoscalerEnabledAnnotation: "true",
+				autoscalerMinAnnotation:     "1",
+				autoscalerMaxAnnotation:     "5",
+			},
+		},
+	}
+
+	d := newDiscoverer([]jujuControllerConfig{{Name: "controller-1", Models: []string{"model-1"}}}, nil, DrainOptions{}, DestroyPolicy{}, nil, nil)
+	d.dial = func(jujuControllerConfig, string) (discoveryClient, error) { return client, nil }
+	d.newNodeGroup = func(_ jujuControllerConfig, _ kube_client.Interface, model, application string, minSize, maxSize int, _ DrainOptions, _ DestroyPolicy, _ *stateStore, _ []pricingEntry, _ string, _ []string) (*NodeGroup, error) {
+		return &NodeGroup{id: "juju-" + model + "-" + application, application: application, minSize: minSize, maxSize: maxSize, manager: &Manager{apps: map[string]*applicationState{application: {units: map[string]*Unit{}}}}}, nil
+	}
+
+	if _, err := d.discover(); err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if client.closed {
+		t.Fatalf("expected the connection to stay open while its application is still enabled")
+	}
+
+	client.annotations = map[string]map[string]string{}
+	if _, err := d.discover(); err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if !client.closed {
+		t.Fatalf("expected the connection to be closed once its last application was removed")
+	}
+	if len(d.apis) != 0 {
+		t.Fatalf("expected the closed connection to be dropped from d.apis, got %v", d.apis)
+	}
+}
+
+func TestDiscoverKeepsConnectionOpenWhileAnyApplicationRemains(t *testing.T) {
+	client := &fakeDiscoveryClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{"workers": {}, "db": {}},
+		},
+		annotations: map[string]map[string]string{
+			"workers": {autoscalerEnabledAnnotation: "true", autoscalerMinAnnotation: "1", autoscalerMaxAnnotation: "5"},
+			"db":      {autoscalerEnabledAnnotation: "true", autoscalerMinAnnotation: "1", autoscalerMaxAnnotation: "3"},
+		},
+	}
+
+	d := newDiscoverer([]jujuControllerConfig{{Name: "controller-1", Models: []string{"model-1"}}}, nil, DrainOptions{}, DestroyPolicy{}, nil, nil)
+	d.dial = func(jujuControllerConfig, string) (discoveryClient, error) { return client, nil }
+	d.newNodeGroup = func(_ jujuControllerConfig, _ kube_client.Interface, model, application string, minSize, maxSize int, _ DrainOptions, _ DestroyPolicy, _ *stateStore, _ []pricingEntry, _ string, _ []string) (*NodeGroup, error) {
+		return &NodeGroup{id: "juju-" + model + "-" + application, application: application, minSize: minSize, maxSize: maxSize, manager: &Manager{apps: map[string]*applicationState{application: {units: map[string]*Unit{}}}}}, nil
+	}
+
+	if _, err := d.discover(); err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+
+	// Only "db" stops being enabled; "workers" still shares the same
+	// controller:model connection, so it must stay open.
+	client.annotations = map[string]map[string]string{
+		"workers": {autoscalerEnabledAnnotation: "true", autoscalerMinAnnotation: "1", autoscalerMaxAnnotation: "5"},
+	}
+	if _, err := d.discover(); err != nil {
+		t.Fatalf("discover() returned error: %v", err)
+	}
+	if client.closed {
+		t.Fatalf("expected the shared connection to stay open while workers is still enabled")
+	}
+	if len(d.apis) != 1 {
+		t.Fatalf("expected the shared connection to remain cached, got %v", d.apis)
+	}
+}
+
+func TestCloseClosesEveryCachedConnection(t *testing.T) {
+	client := &fakeDiscoveryClient{}
+	d := newDiscoverer(nil, nil, DrainOptions{}, DestroyPolicy{}, nil, nil)
+	d.apis["controller-1:model-1"] = client
+
+	d.close()
+
+	if !client.closed {
+		t.Fatalf("expected close() to close every cached connection")
+	}
+	if len(d.apis) != 0 {
+		t.Fatalf("expected close() to drop every cached connection, got %v", d.apis)
+	}
+}
+
+func TestScalingBoundsRequiresEnabledAnnotation(t *testing.T) {
+	if _, _, ok := scalingBounds(map[string]string{autoscalerMinAnnotation: "1", autoscalerMaxAnnotation: "3"}); ok {
+		t.Fatalf("expected application without enabled annotation to be skipped")
+	}
+}
+
+func TestScalingBoundsParsesMinMax(t *testing.T) {
+	min, max, ok := scalingBounds(map[string]string{
+		autoscalerEnabledAnnotation: "true",
+		autoscalerMinAnnotation:     "1",
+		autoscalerMaxAnnotation:     "5",
+	})
+	if !ok || min != 1 || max != 5 {
+		t.Fatalf("scalingBounds() = (%d, %d, %v), want (1, 5, true)", min, max, ok)
+	}
+}
+
+func TestScalingBoundsInvalidNumber(t *testing.T) {
+	if _, _, ok := scalingBounds(map[string]string{
+		autoscalerEnabledAnnotation: "true",
+		autoscalerMinAnnotation:     "not-a-number",
+		autoscalerMaxAnnotation:     "5",
+	}); ok {
+		t.Fatalf("expected invalid min annotation to be rejected")
+	}
+}
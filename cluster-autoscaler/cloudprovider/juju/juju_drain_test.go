@@ -0,0 +1,460 @@
+package juju
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juju/juju/api/client/action"
+	"github.com/juju/juju/api/client/application"
+	"github.com/juju/juju/core/constraints"
+	"github.com/juju/juju/rpc/params"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	kube_fake "k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeJujuClient is a hand-rolled JujuClient double: the mocks package the
+// go:generate directives at the top of juju_manager.go point to isn't
+// vendored in this tree, so tests supply their own minimal fake instead.
+type fakeJujuClient struct {
+	status              *params.FullStatus
+	charmActions        map[string]action.ActionSpec
+	actionStatus        string
+	destroyCalls        []application.DestroyUnitsParams
+	destroyResults      []params.DestroyUnitResult // returned verbatim by DestroyUnits, if set
+	unitRemovedAt       int                        // number of DestroyUnits calls after which the unit disappears from status
+	constraints         constraints.Value
+	setConstraintsCalls []constraints.Value
+	addUnitsCalls       []application.AddUnitsParams
+	addUnitsErr         error
+}
+
+func (f *fakeJujuClient) AddUnits(args application.AddUnitsParams) ([]string, error) {
+	f.addUnitsCalls = append(f.addUnitsCalls, args)
+	if f.addUnitsErr != nil {
+		return nil, f.addUnitsErr
+	}
+	return nil, nil
+}
+
+func (f *fakeJujuClient) DestroyUnits(args application.DestroyUnitsParams) ([]params.DestroyUnitResult, error) {
+	f.destroyCalls = append(f.destroyCalls, args)
+	if len(f.destroyCalls) >= f.unitRemovedAt && f.unitRemovedAt > 0 {
+		app := f.status.Applications["workers"]
+		delete(app.Units, args.Units[0])
+		f.status.Applications["workers"] = app
+	}
+	return f.destroyResults, nil
+}
+
+func (f *fakeJujuClient) Status(patterns []string) (*params.FullStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeJujuClient) ApplicationCharmActions(applicationName string) (map[string]action.ActionSpec, error) {
+	return f.charmActions, nil
+}
+
+func (f *fakeJujuClient) RunAction(unitName string, actionName string) (string, error) {
+	return "1", nil
+}
+
+func (f *fakeJujuClient) WaitForAction(actionID string, timeout time.Duration) (string, error) {
+	return f.actionStatus, nil
+}
+
+func (f *fakeJujuClient) ApplicationConstraints(applicationName string) (constraints.Value, error) {
+	return f.constraints, nil
+}
+
+func (f *fakeJujuClient) SetConstraints(applicationName string, cons constraints.Value) error {
+	f.setConstraintsCalls = append(f.setConstraintsCalls, cons)
+	return nil
+}
+
+func newTestManager(t *testing.T, jujuClient *fakeJujuClient, objects ...runtime.Object) *Manager {
+	t.Helper()
+	kubeClient := kube_fake.NewSimpleClientset(objects...)
+	m := NewManager(jujuClient, kubeClient, "model-1", DrainOptions{
+		GracePeriod: time.Second,
+		Timeout:     time.Second,
+		ForceAfter:  time.Millisecond,
+	}, nil)
+	if err := m.AddApplication("workers", 0, 0, "juju-model-1-workers", "", nil, DestroyPolicy{Force: true}); err != nil {
+		t.Fatalf("AddApplication() returned error: %v", err)
+	}
+	return m
+}
+
+func TestRefreshLabelsNewNodeWithGPUType(t *testing.T) {
+	gpuTags := []string{"gpu=nvidia-tesla-k80"}
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{
+				"workers/0": {Machine: "0", WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}},
+			}}},
+			Machines: map[string]params.MachineStatus{"0": {Hostname: "node-1"}},
+		},
+		constraints: constraints.Value{Tags: &gpuTags},
+	}
+	m := newTestManager(t, client, node)
+
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+
+	got, err := m.kubeClient.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if got.Labels[GPULabel] != "nvidia-tesla-k80" {
+		t.Fatalf("got GPULabel %q, want %q", got.Labels[GPULabel], "nvidia-tesla-k80")
+	}
+}
+
+func TestEnsureGPULabelIsIdempotent(t *testing.T) {
+	client := &fakeJujuClient{status: &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}}}
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	m := newTestManager(t, client, node)
+	app := m.apps["workers"]
+	app.gpuType = "nvidia-tesla-k80"
+
+	if err := m.ensureGPULabel(app, node); err != nil {
+		t.Fatalf("ensureGPULabel() returned error: %v", err)
+	}
+	if node.Labels[GPULabel] != "nvidia-tesla-k80" {
+		t.Fatalf("got GPULabel %q, want %q", node.Labels[GPULabel], "nvidia-tesla-k80")
+	}
+
+	// Already labeled: ensureGPULabel must be a no-op rather than issue a
+	// redundant Update.
+	if err := m.ensureGPULabel(app, node); err != nil {
+		t.Fatalf("ensureGPULabel() on an already-labeled node returned error: %v", err)
+	}
+}
+
+func TestAddApplicationSetsConstraintsAndRejectsInvalidPlacement(t *testing.T) {
+	client := &fakeJujuClient{status: &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}}}
+	kubeClient := kube_fake.NewSimpleClientset()
+	m := NewManager(client, kubeClient, "model-1", DrainOptions{}, nil)
+
+	if err := m.AddApplication("workers", 0, 5, "juju-model-1-workers", "mem=8G cores=4", []string{"not a valid directive"}, DestroyPolicy{}); err == nil {
+		t.Fatalf("expected an error for an invalid placement directive")
+	}
+
+	if err := m.AddApplication("workers", 0, 5, "juju-model-1-workers", "mem=8G cores=4", []string{"zone=us-east-1a"}, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication() returned error: %v", err)
+	}
+	if len(client.setConstraintsCalls) != 1 {
+		t.Fatalf("expected SetConstraints to be called once, got %d calls", len(client.setConstraintsCalls))
+	}
+	got := client.setConstraintsCalls[0]
+	if got.Mem == nil || *got.Mem != 8192 || got.CpuCores == nil || *got.CpuCores != 4 {
+		t.Fatalf("SetConstraints called with %+v, want mem=8192 cores=4", got)
+	}
+	placement := m.apps["workers"].placement
+	if len(placement) != 1 || placement[0].Scope != "zone" || placement[0].Directive != "us-east-1a" {
+		t.Fatalf("got placement %+v, want a single zone=us-east-1a directive", placement)
+	}
+}
+
+func TestAddUnitsPassesPlacementToAddUnitsParams(t *testing.T) {
+	client := &fakeJujuClient{status: &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}}}
+	kubeClient := kube_fake.NewSimpleClientset()
+	m := NewManager(client, kubeClient, "model-1", DrainOptions{}, nil)
+	if err := m.AddApplication("workers", 0, 5, "juju-model-1-workers", "", []string{"zone=us-east-1a"}, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication() returned error: %v", err)
+	}
+
+	if err := m.addUnits("workers", 1); err != nil {
+		t.Fatalf("addUnits() returned error: %v", err)
+	}
+	if len(client.addUnitsCalls) != 1 {
+		t.Fatalf("expected AddUnits to be called once, got %d calls", len(client.addUnitsCalls))
+	}
+	got := client.addUnitsCalls[0].Placement
+	if len(got) != 1 || got[0].Scope != "zone" || got[0].Directive != "us-east-1a" {
+		t.Fatalf("got placement %+v, want a single zone=us-east-1a directive", got)
+	}
+}
+
+func TestCordonNodeMarksUnschedulable(t *testing.T) {
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	client := &fakeJujuClient{status: &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}}}
+	m := newTestManager(t, client, node)
+
+	if err := m.cordonNode("node-1"); err != nil {
+		t.Fatalf("cordonNode() returned error: %v", err)
+	}
+
+	got, err := m.kubeClient.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if !got.Spec.Unschedulable {
+		t.Fatalf("expected node to be marked unschedulable")
+	}
+}
+
+func TestPauseUnitSkippedWhenNoPauseAction(t *testing.T) {
+	client := &fakeJujuClient{
+		status:       &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}},
+		charmActions: map[string]action.ActionSpec{},
+	}
+	m := newTestManager(t, client)
+
+	if err := m.pauseUnit("workers", "workers/0", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("pauseUnit() returned error: %v", err)
+	}
+}
+
+func TestPauseUnitRunsActionWhenAvailable(t *testing.T) {
+	client := &fakeJujuClient{
+		status:       &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}},
+		charmActions: map[string]action.ActionSpec{pauseActionName: {}},
+		actionStatus: "completed",
+	}
+	m := newTestManager(t, client)
+
+	if err := m.pauseUnit("workers", "workers/0", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("pauseUnit() returned error: %v", err)
+	}
+}
+
+func TestPauseUnitReturnsErrorOnActionFailure(t *testing.T) {
+	client := &fakeJujuClient{
+		status:       &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}},
+		charmActions: map[string]action.ActionSpec{pauseActionName: {}},
+		actionStatus: "failed",
+	}
+	m := newTestManager(t, client)
+
+	if err := m.pauseUnit("workers", "workers/0", time.Now().Add(time.Second)); err == nil {
+		t.Fatalf("expected an error when the pause action fails")
+	}
+}
+
+func TestRemoveUnitGracefulSuccessNeverEscalates(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					"workers/0": {Machine: "0"},
+				}},
+			},
+			Machines: map[string]params.MachineStatus{"0": {Hostname: "node-1"}},
+		},
+		charmActions:  map[string]action.ActionSpec{},
+		unitRemovedAt: 1, // gone from status as soon as the first DestroyUnits call lands
+	}
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	m := newTestManager(t, client, node)
+
+	if err := m.removeUnit("workers", "node-1"); err != nil {
+		t.Fatalf("removeUnit() returned error: %v", err)
+	}
+	if len(client.destroyCalls) != 1 || client.destroyCalls[0].Force {
+		t.Fatalf("expected a single graceful DestroyUnits call, got %+v", client.destroyCalls)
+	}
+
+	time.Sleep(2 * time.Millisecond) // past DrainOptions.ForceAfter
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+
+	if len(client.destroyCalls) != 1 {
+		t.Fatalf("expected refresh() not to escalate an already-gone unit, got %d calls", len(client.destroyCalls))
+	}
+	if _, ok := m.apps["workers"].units["workers/0"]; ok {
+		t.Fatalf("expected workers/0 to have been dropped from managed units")
+	}
+}
+
+// TestRemoveUnitEscalatesToForceOnRefresh exercises the case the previous
+// test doesn't: a unit that's still present after DrainOptions.ForceAfter
+// gets retried with Force: true on a later refresh, not inside removeUnit
+// itself.
+func TestRemoveUnitEscalatesToForceOnRefresh(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					"workers/0": {Machine: "0"},
+				}},
+			},
+			Machines: map[string]params.MachineStatus{"0": {Hostname: "node-1"}},
+		},
+		charmActions:  map[string]action.ActionSpec{},
+		unitRemovedAt: 0, // never disappears on its own
+	}
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	m := newTestManager(t, client, node)
+
+	if err := m.removeUnit("workers", "node-1"); err != nil {
+		t.Fatalf("removeUnit() returned error: %v", err)
+	}
+	if len(client.destroyCalls) != 1 || client.destroyCalls[0].Force {
+		t.Fatalf("expected a single graceful DestroyUnits call from removeUnit(), got %+v", client.destroyCalls)
+	}
+
+	// A refresh before the grace period elapses must not escalate yet.
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+	if len(client.destroyCalls) != 1 {
+		t.Fatalf("expected refresh() not to escalate before ForceAfter elapses, got %d calls", len(client.destroyCalls))
+	}
+
+	time.Sleep(2 * time.Millisecond) // past DrainOptions.ForceAfter
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+
+	if len(client.destroyCalls) != 2 {
+		t.Fatalf("expected a second, forced DestroyUnits call once ForceAfter elapsed, got %d", len(client.destroyCalls))
+	}
+	if !client.destroyCalls[1].Force {
+		t.Fatalf("expected the second DestroyUnits call to be forced (Force: true)")
+	}
+
+	// A further refresh must not reissue the forced call yet again.
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+	if len(client.destroyCalls) != 2 {
+		t.Fatalf("expected refresh() not to reissue the forced call, got %d calls", len(client.destroyCalls))
+	}
+}
+
+// TestRemoveUnitLogsPerUnitDestroyError exercises DestroyUnits returning a
+// per-unit error alongside a nil top-level error (e.g. the unit ID was
+// already invalid by the time Juju processed it): removeUnit must not treat
+// that as a failure of the whole call.
+func TestRemoveUnitLogsPerUnitDestroyError(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					"workers/0": {Machine: "0"},
+				}},
+			},
+			Machines: map[string]params.MachineStatus{"0": {Hostname: "node-1"}},
+		},
+		charmActions: map[string]action.ActionSpec{},
+		destroyResults: []params.DestroyUnitResult{
+			{Error: &params.Error{Message: `unit "workers/0" not found`}},
+		},
+	}
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	m := newTestManager(t, client, node)
+
+	if err := m.removeUnit("workers", "node-1"); err != nil {
+		t.Fatalf("removeUnit() returned error: %v, want the per-unit error to only be logged", err)
+	}
+}
+
+func TestManagerHandlesMultipleApplicationsIndependently(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					"workers/0": {Machine: "0", WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}},
+				}},
+				"db": {Units: map[string]params.UnitStatus{
+					"db/0": {Machine: "1", WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}},
+				}},
+			},
+			Machines: map[string]params.MachineStatus{
+				"0": {Hostname: "node-workers"},
+				"1": {Hostname: "node-db"},
+			},
+		},
+		charmActions: map[string]action.ActionSpec{},
+	}
+	kubeClient := kube_fake.NewSimpleClientset()
+	m := NewManager(client, kubeClient, "model-1", DrainOptions{
+		GracePeriod: time.Second,
+		Timeout:     time.Second,
+		ForceAfter:  time.Millisecond,
+	}, nil)
+	if err := m.AddApplication("workers", 0, 5, "ng-workers", "", nil, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication(workers) returned error: %v", err)
+	}
+	if err := m.AddApplication("db", 0, 3, "ng-db", "", nil, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication(db) returned error: %v", err)
+	}
+
+	// Scale up workers...
+	if err := m.addUnits("workers", 1); err != nil {
+		t.Fatalf("addUnits(workers) returned error: %v", err)
+	}
+	if len(client.addUnitsCalls) != 1 || client.addUnitsCalls[0].ApplicationName != "workers" {
+		t.Fatalf("expected AddUnits to be called once for workers, got %+v", client.addUnitsCalls)
+	}
+
+	// ...while db scales down, in the same manager.
+	if err := m.removeUnit("db", "node-db"); err != nil {
+		t.Fatalf("removeUnit(db) returned error: %v", err)
+	}
+	for _, call := range client.destroyCalls {
+		if len(call.Units) != 1 || call.Units[0] != "db/0" {
+			t.Fatalf("expected DestroyUnits to only ever target db/0, got %+v", call)
+		}
+	}
+
+	if _, ok := m.apps["workers"].units["workers/0"]; !ok {
+		t.Fatalf("expected workers/0 to be untouched by db's scale-down")
+	}
+	if m.apps["db"].units["db/0"].state != cloudprovider.InstanceDeleting {
+		t.Fatalf("expected db/0 to be marked InstanceDeleting")
+	}
+}
+
+// TestRefreshDoesNotPerturbOtherApplications exercises refresh()'s single
+// Status round-trip across two applications: an externally-added unit on
+// one application must not affect the other's managed units.
+func TestRefreshDoesNotPerturbOtherApplications(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					"workers/0": {Machine: "0", WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}},
+				}},
+				"db": {Units: map[string]params.UnitStatus{}},
+			},
+			Machines: map[string]params.MachineStatus{"0": {Hostname: "node-workers"}},
+		},
+	}
+	kubeClient := kube_fake.NewSimpleClientset()
+	m := NewManager(client, kubeClient, "model-1", DrainOptions{}, nil)
+	if err := m.AddApplication("workers", 0, 5, "ng-workers", "", nil, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication(workers) returned error: %v", err)
+	}
+	if err := m.AddApplication("db", 0, 3, "ng-db", "", nil, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication(db) returned error: %v", err)
+	}
+
+	// An admin manually adds a unit to db outside the autoscaler.
+	dbApp := client.status.Applications["db"]
+	dbApp.Units["db/0"] = params.UnitStatus{Machine: "1", WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}}
+	client.status.Applications["db"] = dbApp
+	client.status.Machines["1"] = params.MachineStatus{Hostname: "node-db"}
+
+	if err := m.refresh(); err != nil {
+		t.Fatalf("refresh() returned error: %v", err)
+	}
+
+	if _, ok := m.apps["db"].units["db/0"]; !ok {
+		t.Fatalf("expected refresh() to pick up the externally-added db/0 unit")
+	}
+	if len(m.apps["workers"].units) != 1 {
+		t.Fatalf("expected workers' managed units to be unaffected, got %d", len(m.apps["workers"].units))
+	}
+	if _, ok := m.apps["workers"].units["workers/0"]; !ok {
+		t.Fatalf("expected workers/0 to remain managed")
+	}
+}
@@ -0,0 +1,40 @@
+package juju
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnitNotFound is returned when Manager is asked to act on a unit (e.g.
+// by hostname) that isn't currently tracked for the given application.
+var ErrUnitNotFound = errors.New("unit not found")
+
+// ErrTransientAPI wraps a Juju API error RetryStrategy judged transient
+// (connection reset, EOF, ...) and gave up retrying, so callers can treat it
+// as retryable rather than a permanent failure.
+var ErrTransientAPI = errors.New("transient juju api error")
+
+// ErrQuotaExceeded is returned when Juju rejects a request because it would
+// exceed model, account, or controller quota.
+var ErrQuotaExceeded = errors.New("juju quota exceeded")
+
+// ErrPlacementInvalid is returned when a placement directive given to
+// AddApplication doesn't parse.
+var ErrPlacementInvalid = errors.New("invalid juju placement")
+
+// IsUnitNotFound reports whether err is, or wraps, ErrUnitNotFound.
+func IsUnitNotFound(err error) bool { return errors.Is(err, ErrUnitNotFound) }
+
+// IsTransient reports whether err is, or wraps, ErrTransientAPI.
+func IsTransient(err error) bool { return errors.Is(err, ErrTransientAPI) }
+
+// isQuotaExceededError reports whether err looks like Juju rejecting a
+// request for exceeding quota, the same string-sniffing approach
+// isTransientAPIError uses, since Juju's facade errors don't carry a typed
+// quota error our client-side dependency exposes.
+func isQuotaExceededError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "quota")
+}
@@ -0,0 +1,79 @@
+package juju
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/juju/juju/rpc/params"
+	kube_fake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRemoveUnitUnknownHostnameIsUnitNotFound(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{}},
+			},
+		},
+	}
+	m := newTestManager(t, client)
+
+	err := m.removeUnit("workers", "node-does-not-exist")
+	if !IsUnitNotFound(err) {
+		t.Fatalf("IsUnitNotFound(%v) = false, want true", err)
+	}
+	if !errors.Is(err, ErrUnitNotFound) {
+		t.Fatalf("errors.Is(%v, ErrUnitNotFound) = false, want true", err)
+	}
+}
+
+func TestAddUnitsQuotaExceededIsClassified(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{}},
+			},
+		},
+		addUnitsErr: errors.New("application quota exceeded"),
+	}
+	m := newTestManager(t, client)
+
+	err := m.addUnits("workers", 1)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("errors.Is(%v, ErrQuotaExceeded) = false, want true", err)
+	}
+}
+
+func TestAddUnitsOtherErrorIsNotQuotaExceeded(t *testing.T) {
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{}},
+			},
+		},
+		addUnitsErr: errors.New("connection refused"),
+	}
+	m := newTestManager(t, client)
+
+	err := m.addUnits("workers", 1)
+	if errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("errors.Is(%v, ErrQuotaExceeded) = true, want false", err)
+	}
+}
+
+func TestParsePlacementInvalidDirectiveIsPlacementInvalid(t *testing.T) {
+	_, err := parsePlacement([]string{"!!!not-a-valid-directive!!!"})
+	if !errors.Is(err, ErrPlacementInvalid) {
+		t.Fatalf("errors.Is(%v, ErrPlacementInvalid) = false, want true", err)
+	}
+}
+
+func TestAddApplicationInvalidPlacementIsPlacementInvalid(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	m := NewManager(&fakeJujuClient{}, kubeClient, "model-1", DrainOptions{}, nil)
+
+	err := m.AddApplication("workers", 0, 0, "juju-model-1-workers", "", []string{"!!!bad!!!"}, DestroyPolicy{})
+	if !errors.Is(err, ErrPlacementInvalid) {
+		t.Fatalf("errors.Is(%v, ErrPlacementInvalid) = false, want true", err)
+	}
+}
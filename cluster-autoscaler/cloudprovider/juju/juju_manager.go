@@ -7,80 +7,312 @@ package juju
 import (
 	ctx "context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/juju/juju/api/client/action"
 	"github.com/juju/juju/api/client/application"
+	"github.com/juju/juju/core/constraints"
+	"github.com/juju/juju/core/instance"
 	"github.com/juju/juju/rpc/params"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	kube_client "k8s.io/client-go/kubernetes"
 	klog "k8s.io/klog/v2"
 )
 
+// pauseActionName is the conventional name charms use for an action that
+// gracefully quiesces a workload before its unit is removed.
+const pauseActionName = "pause"
+
 type Unit struct {
 	state      cloudprovider.InstanceState
 	jujuName   string
 	hostname   string
 	status     params.UnitStatus
 	providerID string
+
+	// destroyRequestedAt records when removeUnit first asked Juju to
+	// destroy this unit, so refresh can tell once the manager's
+	// ForceAfter grace period has elapsed without the unit actually
+	// disappearing.
+	destroyRequestedAt time.Time
+	// forced records whether the forced (Force: true) retry has already
+	// been issued for this unit, so refresh doesn't reissue it every
+	// cycle while Juju works through a slow forced removal.
+	forced bool
+}
+
+// DestroyPolicy controls how removeUnit destroys a unit once it's done
+// draining: whether its attached storage is destroyed instead of left
+// detached, whether a unit still present after the manager's ForceAfter
+// grace period gets escalated to Force: true on a later refresh, and how
+// long Juju should wait between each step of that forced removal
+// (DestroyUnitsParams.MaxWait; only meaningful once Force is true).
+type DestroyPolicy struct {
+	DestroyStorage bool
+	Force          bool
+	MaxWait        time.Duration
 }
 
 type JujuClient interface {
 	AddUnits(args application.AddUnitsParams) ([]string, error)
 	DestroyUnits(args application.DestroyUnitsParams) ([]params.DestroyUnitResult, error)
 	Status(patterns []string) (*params.FullStatus, error)
+	ApplicationCharmActions(applicationName string) (map[string]action.ActionSpec, error)
+	RunAction(unitName string, actionName string) (string, error)
+	WaitForAction(actionID string, timeout time.Duration) (string, error)
+	ApplicationConstraints(applicationName string) (constraints.Value, error)
+	SetConstraints(applicationName string, cons constraints.Value) error
 }
 
-type Manager struct {
-	jujuClient  JujuClient
-	kubeClient  kube_client.Interface
-	model       string
-	application string
+// DrainOptions bounds the cordon/pause/drain sequence removeUnit runs before
+// destroying a unit, so a slow or stuck workload can't block scale-down
+// forever.
+type DrainOptions struct {
+	// GracePeriod is passed through to each pod eviction as the deletion
+	// grace period.
+	GracePeriod time.Duration
+	// Timeout bounds how long removeUnit waits for the pause action and pod
+	// evictions to finish before calling DestroyUnits.
+	Timeout time.Duration
+	// ForceAfter bounds how long a unit may sit in InstanceDeleting after a
+	// graceful DestroyUnits (Force: false) before refresh escalates it to
+	// Force: true, for applications whose DestroyPolicy enables Force.
+	ForceAfter time.Duration
+}
+
+// applicationState tracks everything Manager needs to autoscale one Juju
+// application: its managed units, scaling bounds, machine constraints/
+// placement, and the node group ID its persisted unit entries are scoped
+// under in the shared state store.
+type applicationState struct {
 	units       map[string]*Unit
+	minSize     int
+	maxSize     int
+	nodeGroupID string
+
+	// cons is the application's Juju machine constraints, fetched once when
+	// the application is added; gpuType is derived from cons' "gpu=" tag, if
+	// any, and applied as a node label by refresh.
+	cons    constraints.Value
+	gpuType string
+
+	// placement holds the parsed placement directives new units are
+	// created with, letting different applications target different
+	// zones/hardware (e.g. "zone=us-east-1a").
+	placement []*instance.Placement
+
+	// destroy controls how removeUnit destroys this application's units.
+	destroy DestroyPolicy
 }
 
-func NewManager(jujuClient JujuClient, kubeClient kube_client.Interface, model string, application string) (*Manager, error) {
+// Manager drives one or more Juju applications within a single model,
+// letting a model expose more than one autoscaling worker group without
+// dialing the controller separately for each.
+type Manager struct {
+	jujuClient JujuClient
+	kubeClient kube_client.Interface
+	model      string
+	drain      DrainOptions
+
+	// store persists each application's unit state across restarts. A nil
+	// store disables persistence entirely.
+	store *stateStore
+
+	apps map[string]*applicationState
+}
+
+// NewManager creates a Manager for model with no managed applications yet;
+// call AddApplication once per Juju application it should autoscale.
+func NewManager(jujuClient JujuClient, kubeClient kube_client.Interface, model string, drain DrainOptions, store *stateStore) *Manager {
 	klog.Infof("creating manager")
-	m := new(Manager)
-	m.jujuClient = jujuClient
-	m.kubeClient = kubeClient
-	m.model = model
-	m.application = application
-	m.units = make(map[string]*Unit)
+	return &Manager{
+		jujuClient: jujuClient,
+		kubeClient: kubeClient,
+		model:      model,
+		drain:      drain,
+		store:      store,
+		apps:       make(map[string]*applicationState),
+	}
+}
+
+// AddApplication registers application, scaled between minSize and maxSize,
+// as a node group this Manager manages under nodeGroupID, optionally setting
+// its machine constraints to cons (a space-separated constraints string in
+// Juju's own "key=value ..." form, e.g. "mem=8G cores=4 tags=gpu=nvidia") and
+// placing new units with placement (one directive per unit, parsed like
+// Juju's own --to flag, e.g. "zone=us-east-1a" or "lxd:0"), and destroying
+// units according to destroy when scaling down. It then fetches
+// application's current units from Juju and recovers any persisted state for
+// nodeGroupID.
+func (m *Manager) AddApplication(application string, minSize, maxSize int, nodeGroupID string, cons string, placement []string, destroy DestroyPolicy) error {
+	klog.Infof("adding application %s to manager", application)
+
+	parsedPlacement, err := parsePlacement(placement)
+	if err != nil {
+		return fmt.Errorf("invalid placement for %s: %w", application, err)
+	}
+
+	if cons != "" {
+		parsedCons, err := constraints.Parse(cons)
+		if err != nil {
+			return fmt.Errorf("invalid constraints %q for %s: %w", cons, application, err)
+		}
+		if err := m.jujuClient.SetConstraints(application, parsedCons); err != nil {
+			return fmt.Errorf("error setting constraints for %s: %w", application, err)
+		}
+	}
+
+	app := &applicationState{
+		units:       make(map[string]*Unit),
+		minSize:     minSize,
+		maxSize:     maxSize,
+		nodeGroupID: nodeGroupID,
+		placement:   parsedPlacement,
+		destroy:     destroy,
+	}
+
+	liveCons, err := m.jujuClient.ApplicationConstraints(application)
+	if err != nil {
+		klog.Warningf("error getting machine constraints for %s, pricing and GPU labeling will be unavailable: %v", application, err)
+	} else {
+		app.cons = liveCons
+		if gpuType, ok := gpuTypeFromTags(liveCons); ok {
+			app.gpuType = gpuType
+		}
+	}
 
 	fullStatus, err := m.jujuClient.Status(nil)
 	if err != nil {
 		klog.Error("error getting status from juju client", err.Error())
-		return nil, err
+		return err
+	}
+
+	var recovered map[string]UnitState
+	if m.store != nil {
+		recovered, err = m.store.load(nodeGroupID)
+		if err != nil {
+			klog.Errorf("error loading persisted Juju state, continuing without it: %v", err)
+			recovered = nil
+		}
 	}
 
-	app := fullStatus.Applications[m.application]
-	for unitName, unitStatus := range app.Units {
+	m.apps[application] = app
+
+	for unitName, unitStatus := range fullStatus.Applications[application].Units {
 		unitState := cloudprovider.InstanceCreating
 		if unitStatus.WorkloadStatus.Status == "active" && unitStatus.AgentStatus.Status == "idle" {
 			unitState = cloudprovider.InstanceRunning
 		}
-		m.units[unitName] = &Unit{
+		// A persisted InstanceDeleting entry means the unit was mid-removal
+		// when the autoscaler last restarted; preserve that state so
+		// refresh/removeUnit resume draining it instead of treating it as a
+		// freshly-running unit.
+		if recoveredState, ok := recovered[unitName]; ok && recoveredState.State == cloudprovider.InstanceDeleting {
+			unitState = cloudprovider.InstanceDeleting
+		}
+		unit := &Unit{
 			state:    unitState,
 			jujuName: unitName,
 			hostname: fullStatus.Machines[unitStatus.Machine].Hostname,
 			status:   unitStatus,
 		}
+		if unitState == cloudprovider.InstanceDeleting {
+			// Restart the ForceAfter grace period from this restart rather
+			// than trusting however long it had already been stuck before.
+			unit.destroyRequestedAt = time.Now()
+		}
+		app.units[unitName] = unit
+		m.saveUnitState(application, unitName, unitState)
+	}
+
+	for unitName := range recovered {
+		if _, ok := app.units[unitName]; !ok {
+			if err := m.store.delete(unitName); err != nil {
+				klog.Warningf("error pruning stale Juju state entry for unit %s: %v", unitName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Constraints returns application's Juju machine constraints, used by the
+// cloud provider to price its node group and report its GPU type.
+func (m *Manager) Constraints(application string) constraints.Value {
+	app, ok := m.apps[application]
+	if !ok {
+		return constraints.Value{}
+	}
+	return app.cons
+}
+
+// Units returns the units currently managed for application.
+func (m *Manager) Units(application string) map[string]*Unit {
+	app, ok := m.apps[application]
+	if !ok {
+		return nil
 	}
+	return app.units
+}
 
-	return m, nil
+// saveUnitState write-through persists unitName's state for application, if
+// persistence is configured. Errors are logged rather than returned, since a
+// failure to persist shouldn't block the underlying Juju operation that
+// triggered it.
+func (m *Manager) saveUnitState(application, unitName string, state cloudprovider.InstanceState) {
+	if m.store == nil {
+		return
+	}
+	app, ok := m.apps[application]
+	if !ok {
+		return
+	}
+	err := m.store.save(unitName, UnitState{
+		State:       state,
+		Since:       time.Now(),
+		NodeGroupID: app.nodeGroupID,
+	})
+	if err != nil {
+		klog.Warningf("error persisting Juju state for unit %s: %v", unitName, err)
+	}
 }
 
-func (m *Manager) addUnits(delta int) error {
+// deleteUnitState removes unitName's persisted state, if persistence is
+// configured.
+func (m *Manager) deleteUnitState(unitName string) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.delete(unitName); err != nil {
+		klog.Warningf("error deleting persisted Juju state for unit %s: %v", unitName, err)
+	}
+}
+
+func (m *Manager) addUnits(applicationName string, delta int) error {
+	app, ok := m.apps[applicationName]
+	if !ok {
+		return fmt.Errorf("application %s is not managed by this manager", applicationName)
+	}
+
 	prevStatus, err := m.jujuClient.Status(nil)
 	if err != nil {
 		return err
 	}
 
 	_, err = m.jujuClient.AddUnits(application.AddUnitsParams{
-		ApplicationName: m.application,
+		ApplicationName: applicationName,
 		NumUnits:        delta,
+		Placement:       app.placement,
 	})
 	if err != nil {
+		if isQuotaExceededError(err) {
+			return fmt.Errorf("%w: %v", ErrQuotaExceeded, err)
+		}
 		return err
 	}
 
@@ -89,13 +321,14 @@ func (m *Manager) addUnits(delta int) error {
 		return err
 	}
 
-	for unitName, unitStatus := range currentStatus.Applications[m.application].Units {
-		if _, ok := prevStatus.Applications[m.application].Units[unitName]; !ok {
-			m.units[unitName] = &Unit{
+	for unitName, unitStatus := range currentStatus.Applications[applicationName].Units {
+		if _, ok := prevStatus.Applications[applicationName].Units[unitName]; !ok {
+			app.units[unitName] = &Unit{
 				state:    cloudprovider.InstanceCreating,
 				jujuName: unitName,
 				status:   unitStatus,
 			}
+			m.saveUnitState(applicationName, unitName, cloudprovider.InstanceCreating)
 			klog.Infof("added unit %s to managed units", unitName)
 		}
 	}
@@ -103,39 +336,251 @@ func (m *Manager) addUnits(delta int) error {
 	return nil
 }
 
-func (m *Manager) removeUnit(hostname string) error {
-	unit := m.getUnitByHostname(hostname)
+// removeUnit gracefully scales down applicationName's unit running on
+// hostname: it cordons the backing node, invokes the charm's pause action
+// (if it has one) so the workload can quiesce, evicts the node's pods
+// honoring PodDisruptionBudgets, and only then destroys the unit. If the
+// unit is still present after drain.ForceAfter, a later refresh escalates
+// it to Force: true, per the application's DestroyPolicy.
+func (m *Manager) removeUnit(applicationName, hostname string) error {
+	app, ok := m.apps[applicationName]
+	if !ok {
+		return fmt.Errorf("application %s is not managed by this manager", applicationName)
+	}
+
+	unit := m.getUnitByHostname(applicationName, hostname)
 	if unit == nil {
-		return fmt.Errorf("unit with hostname %s not found", hostname)
+		return fmt.Errorf("%w: hostname %s", ErrUnitNotFound, hostname)
 	}
 	unit.state = cloudprovider.InstanceDeleting
+	m.saveUnitState(applicationName, unit.jujuName, cloudprovider.InstanceDeleting)
+
+	deadline := time.Now().Add(m.drain.Timeout)
+
+	if hostname != "" {
+		if err := m.cordonNode(hostname); err != nil {
+			klog.Errorf("error cordoning node %s before removing unit %s: %v", hostname, unit.jujuName, err)
+		}
+	}
 
-	units := []string{unit.jujuName}
+	if err := m.pauseUnit(applicationName, unit.jujuName, deadline); err != nil {
+		klog.Warningf("error pausing unit %s, continuing with drain: %v", unit.jujuName, err)
+	}
+
+	if hostname != "" {
+		if err := m.drainNode(hostname, deadline); err != nil {
+			klog.Warningf("error draining node %s, continuing with unit removal: %v", hostname, err)
+		}
+	}
+
+	if err := m.destroyUnit(app, unit, false); err != nil {
+		return err
+	}
+	unit.destroyRequestedAt = time.Now()
+
+	klog.Infof("unit %s state changed to InstanceDeleting", unit.jujuName)
+	return nil
+}
+
+// destroyUnit calls DestroyUnits for unit under app's DestroyPolicy. force
+// overrides a graceful destroy with Force: true and app.destroy.MaxWait, for
+// refresh's escalation of a unit that hasn't actually gone away. Per-unit
+// errors returned alongside a nil top-level error (e.g. a unit already
+// gone) are logged rather than treated as a failure of the whole call.
+func (m *Manager) destroyUnit(app *applicationState, unit *Unit, force bool) error {
 	args := application.DestroyUnitsParams{
-		Units:          units,
-		DestroyStorage: false,
-		Force:          false,
+		Units:          []string{unit.jujuName},
+		DestroyStorage: app.destroy.DestroyStorage,
+		Force:          force,
+	}
+	if force && app.destroy.MaxWait > 0 {
+		maxWait := app.destroy.MaxWait
+		args.MaxWait = &maxWait
 	}
 
-	_, err := m.jujuClient.DestroyUnits(args)
+	results, err := m.jujuClient.DestroyUnits(args)
 	if err != nil {
 		return err
 	}
 
-	klog.Infof("unit %s state changed to InstanceDeleting", unit.jujuName)
+	for _, result := range results {
+		if result.Error != nil {
+			klog.Warningf("error destroying unit %s: %v", unit.jujuName, result.Error)
+			continue
+		}
+		if result.Info == nil {
+			continue
+		}
+		klog.Infof("destroying unit %s: destroyed storage %v, detached storage %v",
+			unit.jujuName, entityTags(result.Info.DestroyedStorage), entityTags(result.Info.DetachedStorage))
+	}
 	return nil
 }
 
+// entityTags extracts the tag of each entity, for logging DestroyUnitInfo's
+// storage lists.
+func entityTags(entities []params.Entity) []string {
+	tags := make([]string, len(entities))
+	for i, e := range entities {
+		tags[i] = e.Tag
+	}
+	return tags
+}
+
+// cordonNode marks hostname unschedulable so the scheduler stops placing new
+// pods on it while it drains.
+func (m *Manager) cordonNode(hostname string) error {
+	node, err := m.kubeClient.CoreV1().Nodes().Get(ctx.TODO(), hostname, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = true
+	_, err = m.kubeClient.CoreV1().Nodes().Update(ctx.TODO(), node, v1.UpdateOptions{})
+	return err
+}
+
+// pauseUnit invokes the charm's pause action, if it exposes one, and waits
+// for it to finish before the deadline. Applications without a pause action
+// are left alone.
+func (m *Manager) pauseUnit(applicationName, unitName string, deadline time.Time) error {
+	actions, err := m.jujuClient.ApplicationCharmActions(applicationName)
+	if err != nil {
+		return fmt.Errorf("error listing charm actions for %s: %v", applicationName, err)
+	}
+	if _, ok := actions[pauseActionName]; !ok {
+		return nil
+	}
+
+	actionID, err := m.jujuClient.RunAction(unitName, pauseActionName)
+	if err != nil {
+		return fmt.Errorf("error running %s action on %s: %v", pauseActionName, unitName, err)
+	}
+
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		timeout = 0
+	}
+	status, err := m.jujuClient.WaitForAction(actionID, timeout)
+	if err != nil {
+		return err
+	}
+	if status != "completed" {
+		return fmt.Errorf("%s action on %s finished with status %s", pauseActionName, unitName, status)
+	}
+	klog.Infof("unit %s paused via charm action", unitName)
+	return nil
+}
+
+// drainNode evicts every evictable pod scheduled on hostname, honoring
+// PodDisruptionBudgets by retrying evictions that are rejected until
+// deadline passes.
+func (m *Manager) drainNode(hostname string, deadline time.Time) error {
+	pods, err := m.kubeClient.CoreV1().Pods("").List(ctx.TODO(), v1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", hostname),
+	})
+	if err != nil {
+		return fmt.Errorf("error listing pods on node %s: %v", hostname, err)
+	}
+
+	var toEvict []v1.ObjectMeta
+	for _, pod := range pods.Items {
+		if isDaemonSetPod(pod.OwnerReferences) || isMirrorPod(pod.Annotations) {
+			continue
+		}
+		toEvict = append(toEvict, pod.ObjectMeta)
+	}
+
+	gracePeriodSeconds := int64(m.drain.GracePeriod.Seconds())
+	for _, podMeta := range toEvict {
+		eviction := &policyv1.Eviction{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      podMeta.Name,
+				Namespace: podMeta.Namespace,
+			},
+			DeleteOptions: &v1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds},
+		}
+
+		for {
+			err := m.kubeClient.PolicyV1().Evictions(podMeta.Namespace).Evict(ctx.TODO(), eviction)
+			if err == nil || errors.IsNotFound(err) {
+				break
+			}
+			if !errors.IsTooManyRequests(err) || time.Now().After(deadline) {
+				return fmt.Errorf("error evicting pod %s/%s: %v", podMeta.Namespace, podMeta.Name, err)
+			}
+			klog.Infof("pod %s/%s blocked by PodDisruptionBudget, retrying", podMeta.Namespace, podMeta.Name)
+			time.Sleep(5 * time.Second)
+		}
+	}
+
+	klog.Infof("drained %d pod(s) from node %s", len(toEvict), hostname)
+	return nil
+}
+
+// parsePlacement parses each of directives with instance.ParsePlacement,
+// the same placement syntax Juju's own --to flag accepts (a bare machine
+// id or container scope like "lxd:0", or a scoped directive like
+// "zone=us-east-1a"), returning a clear error on the first invalid one.
+func parsePlacement(directives []string) ([]*instance.Placement, error) {
+	placement := make([]*instance.Placement, 0, len(directives))
+	for _, directive := range directives {
+		p, err := instance.ParsePlacement(directive)
+		if err == instance.ErrPlacementScopeMissing {
+			// instance.ParsePlacement only splits on ':', so take a
+			// "scope=value" directive apart here.
+			if scope, value, ok := strings.Cut(directive, "="); ok && scope != "" && value != "" {
+				p, err = &instance.Placement{Scope: scope, Directive: value}, nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: directive %q: %v", ErrPlacementInvalid, directive, err)
+		}
+		if p != nil {
+			placement = append(placement, p)
+		}
+	}
+	return placement, nil
+}
+
+func isDaemonSetPod(owners []v1.OwnerReference) bool {
+	for _, owner := range owners {
+		if owner.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+func isMirrorPod(annotations map[string]string) bool {
+	_, ok := annotations["kubernetes.io/config.mirror"]
+	return ok
+}
+
+// refresh fetches status once and demultiplexes it across every managed
+// application in a single round-trip, rather than polling Juju once per
+// application.
 func (m *Manager) refresh() error {
 	fullStatus, err := m.jujuClient.Status(nil)
 	if err != nil {
 		return err
 	}
 
-	// Loop over the units in the status and update the manager to match
-	// This could mean updating the state of units currently managed by the manager
-	// or incorporating a totally new unit that was added by the cluster-admin manually
-	for unitName, unitStatus := range fullStatus.Applications[m.application].Units {
+	for applicationName, app := range m.apps {
+		m.refreshApplication(applicationName, app, fullStatus)
+	}
+
+	return nil
+}
+
+// refreshApplication updates app to match fullStatus: it could mean updating
+// the state of units currently managed by the manager, or incorporating a
+// totally new unit that was added by the cluster-admin manually, and it
+// never touches any other application's state.
+func (m *Manager) refreshApplication(applicationName string, app *applicationState, fullStatus *params.FullStatus) {
+	for unitName, unitStatus := range fullStatus.Applications[applicationName].Units {
 
 		unitHostname := fullStatus.Machines[unitStatus.Machine].Hostname
 		unitProviderID := ""
@@ -145,59 +590,91 @@ func (m *Manager) refresh() error {
 				klog.Errorf("error getting provider ID for unit %v with hostname %v: %v", unitName, unitHostname, err.Error())
 			} else {
 				unitProviderID = node.Spec.ProviderID
+				if err := m.ensureGPULabel(app, node); err != nil {
+					klog.Errorf("error labeling node %s with GPU type %s: %v", unitHostname, app.gpuType, err)
+				}
 			}
 		}
 
 		// Check if we aren't already managing this unit
-		if _, ok := m.units[unitName]; !ok {
+		if _, ok := app.units[unitName]; !ok {
 			// Check if the unit is active and idle
 			// This is necessary since when a unit gets deleted it does not happen immediately
 			// We want to make sure we only add externally added units, not recently deleted units that are still showing up in status
 			if unitStatus.WorkloadStatus.Status == "active" && unitStatus.AgentStatus.Status == "idle" {
 				// The unit was added manually. Need to add it to the units list as a new unit
-				m.units[unitName] = &Unit{
+				app.units[unitName] = &Unit{
 					state:      cloudprovider.InstanceRunning,
 					jujuName:   unitName,
 					hostname:   unitHostname,
 					status:     unitStatus,
 					providerID: unitProviderID,
 				}
+				m.saveUnitState(applicationName, unitName, cloudprovider.InstanceRunning)
 				klog.Infof("detected unmanaged unit %s", unitName)
 				klog.Infof("added unit %s to managed units", unitName)
 			}
 		} else {
 			// Update the status, hostname, and providerID of each unit
-			m.units[unitName].status = unitStatus
-			m.units[unitName].hostname = unitHostname
-			m.units[unitName].providerID = unitProviderID
+			app.units[unitName].status = unitStatus
+			app.units[unitName].hostname = unitHostname
+			app.units[unitName].providerID = unitProviderID
 		}
 	}
 
-	// Based on the state, decide if we need to delete any units, or update any freshly created units to running
-	for unitName, unit := range m.units {
-		// Check if any unit we are managing does not exist in the list of units we got from status
-		if _, ok := fullStatus.Applications[m.application].Units[unitName]; !ok {
-			// A unit we were managing does not exist in the list of units we got from Juju status.
-			// Change the state to InstanceDeleting so it gets removed below
-			unit.state = cloudprovider.InstanceDeleting
-			klog.Infof("detected managed unit %s that has been removed", unit.jujuName)
+	// Based on the state, decide if we need to delete any units, escalate a
+	// stuck removal, or update any freshly created units to running.
+	for unitName, unit := range app.units {
+		_, present := fullStatus.Applications[applicationName].Units[unitName]
+		if !present {
+			// A unit we were managing does not exist in the list of units we
+			// got from Juju status: Juju has confirmed its removal, so stop
+			// tracking it.
+			delete(app.units, unitName)
+			m.deleteUnitState(unit.jujuName)
+			klog.Infof("removed unit %s from managed units", unit.jujuName)
+			continue
 		}
 
 		if unit.state == cloudprovider.InstanceCreating {
 			if unit.status.WorkloadStatus.Status == "active" && unit.status.AgentStatus.Status == "idle" {
 				unit.state = cloudprovider.InstanceRunning
+				m.saveUnitState(applicationName, unitName, cloudprovider.InstanceRunning)
 			}
 		} else if unit.state == cloudprovider.InstanceDeleting {
-			delete(m.units, unitName)
-			klog.Infof("removed unit %s from managed units", unit.jujuName)
+			if app.destroy.Force && !unit.forced && !unit.destroyRequestedAt.IsZero() &&
+				time.Since(unit.destroyRequestedAt) > m.drain.ForceAfter {
+				klog.Warningf("unit %s did not terminate gracefully within %s, forcing removal", unit.jujuName, m.drain.ForceAfter)
+				if err := m.destroyUnit(app, unit, true); err != nil {
+					klog.Errorf("error forcing removal of unit %s: %v", unit.jujuName, err)
+				} else {
+					unit.forced = true
+				}
+			}
 		}
 	}
+}
 
-	return nil
+// ensureGPULabel sets node's GPULabel to app.gpuType if app's constraints
+// mark it as a GPU workload and the label isn't already set.
+func (m *Manager) ensureGPULabel(app *applicationState, node *apiv1.Node) error {
+	if app.gpuType == "" || node.Labels[GPULabel] == app.gpuType {
+		return nil
+	}
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[GPULabel] = app.gpuType
+	_, err := m.kubeClient.CoreV1().Nodes().Update(ctx.TODO(), node, v1.UpdateOptions{})
+	return err
 }
 
-func (m *Manager) getUnitByHostname(hostname string) *Unit {
-	for _, unit := range m.units {
+func (m *Manager) getUnitByHostname(applicationName, hostname string) *Unit {
+	app, ok := m.apps[applicationName]
+	if !ok {
+		return nil
+	}
+	for _, unit := range app.units {
 		if unit.hostname == hostname {
 			return unit
 		}
@@ -0,0 +1,176 @@
+package juju
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulerframework "k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+var _ cloudprovider.NodeGroup = (*NodeGroup)(nil)
+
+// NodeGroup implements cloudprovider.NodeGroup backed by a single Juju
+// application, scaled by adding/removing units through manager.
+type NodeGroup struct {
+	id          string
+	application string
+	minSize     int
+	maxSize     int
+
+	// target mirrors the node group's last-known size: the number of units
+	// Refresh found for application the last time it ran manager.refresh().
+	target int
+
+	manager *Manager
+
+	// hourlyCost and gpuType are precomputed once from application's Juju
+	// machine constraints at creation time, so jujuPricingModel and
+	// jujuCloudProvider.GetAvailableGPUTypes don't need to dial Juju again.
+	hourlyCost float64
+	gpuType    string
+}
+
+// MaxSize returns maximum size of the node group.
+func (n *NodeGroup) MaxSize() int {
+	return n.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (n *NodeGroup) MinSize() int {
+	return n.minSize
+}
+
+// TargetSize returns the current target size of the node group, as of the
+// last Refresh.
+func (n *NodeGroup) TargetSize() (int, error) {
+	return n.target, nil
+}
+
+// IncreaseSize increases the size of the node group by adding delta units to
+// its Juju application. The delta must be positive.
+func (n *NodeGroup) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive, got: %d", delta)
+	}
+	newSize := n.target + delta
+	if newSize > n.maxSize {
+		return fmt.Errorf("size increase too large, desired: %d, max: %d", newSize, n.maxSize)
+	}
+	if err := n.manager.addUnits(n.application, delta); err != nil {
+		return err
+	}
+	n.target = newSize
+	return nil
+}
+
+// DecreaseTargetSize decreases the target size of the node group. The delta
+// must be negative, and should only ever be used to reflect capacity that
+// has already been removed out-of-band, not to trigger removal itself.
+func (n *NodeGroup) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative, got: %d", delta)
+	}
+	newSize := n.target + delta
+	if newSize < 0 {
+		return fmt.Errorf("size decrease too large, desired: %d", newSize)
+	}
+	n.target = newSize
+	return nil
+}
+
+// DeleteNodes drains and removes the Juju unit backing each node, then
+// decrements the target size by the number actually removed.
+func (n *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	deleted := 0
+	for _, node := range nodes {
+		if err := n.manager.removeUnit(n.application, node.Name); err != nil {
+			return fmt.Errorf("failed to remove unit for node %s in node group %s: %w", node.Name, n.id, err)
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return nil
+	}
+	return n.DecreaseTargetSize(-deleted)
+}
+
+// Id returns the node group identifier.
+func (n *NodeGroup) Id() string {
+	return n.id
+}
+
+// Debug returns a string containing all information regarding this node
+// group.
+func (n *NodeGroup) Debug() string {
+	return fmt.Sprintf("%s (min: %d, max: %d, target: %d)", n.id, n.minSize, n.maxSize, n.target)
+}
+
+// Nodes returns the instances belonging to this node group, keyed by the
+// ProviderID of the Kubernetes node backing each running unit. Units without
+// a hostname yet resolved to a node (still provisioning) are omitted.
+func (n *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	units := n.manager.Units(n.application)
+	instances := make([]cloudprovider.Instance, 0, len(units))
+	for _, unit := range units {
+		if unit.providerID == "" {
+			continue
+		}
+		instances = append(instances, cloudprovider.Instance{Id: unit.providerID})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a theoretical node template for scale-up
+// simulation, labeled with this node group's GPU type (if any) and the
+// juju/node-group-id label jujuPricingModel uses to price template nodes
+// that don't have a ProviderID yet.
+func (n *NodeGroup) TemplateNodeInfo() (*schedulerframework.NodeInfo, error) {
+	labels := map[string]string{nodeGroupIDLabel: n.id}
+	if n.gpuType != "" {
+		labels[GPULabel] = n.gpuType
+	}
+
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   n.id,
+			Labels: labels,
+		},
+	}
+
+	nodeInfo := schedulerframework.NewNodeInfo()
+	nodeInfo.SetNode(node)
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+// Juju node groups always correspond to a Manager-tracked application, so
+// this is always true once constructed.
+func (n *NodeGroup) Exist() bool {
+	return true
+}
+
+// Create creates the node group on the cloud provider side. Not supported:
+// Juju node groups always correspond to a pre-existing application.
+func (n *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete deletes the node group on the cloud provider side. Not supported,
+// for the same reason as Create.
+func (n *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (n *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions returns NodeGroupAutoscalingOptions that should be used for
+// this particular NodeGroup. Using default options.
+func (n *NodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return &defaults, nil
+}
@@ -0,0 +1,121 @@
+package juju
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/juju/core/constraints"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// pricingEntry is one row of the cloud config's pricing: table, giving the
+// hourly cost for machines matching either a specific instance-type or a
+// (cores, mem, root-disk) resource tuple.
+type pricingEntry struct {
+	InstanceType string  `yaml:"instance-type"`
+	Cores        uint64  `yaml:"cores"`
+	MemMB        uint64  `yaml:"mem"`
+	RootDiskMB   uint64  `yaml:"root-disk"`
+	HourlyCost   float64 `yaml:"hourly-cost"`
+}
+
+// gpuTagPrefix marks a Juju constraints tag as naming the node's GPU type,
+// e.g. "gpu=nvidia-tesla-k80".
+const gpuTagPrefix = "gpu="
+
+// gpuTypeFromTags returns the GPU type named by a "gpu=<type>" constraint
+// tag, and ok=false if cons has no such tag.
+func gpuTypeFromTags(cons constraints.Value) (gpuType string, ok bool) {
+	if cons.Tags == nil {
+		return "", false
+	}
+	for _, tag := range *cons.Tags {
+		if strings.HasPrefix(tag, gpuTagPrefix) {
+			return strings.TrimPrefix(tag, gpuTagPrefix), true
+		}
+	}
+	return "", false
+}
+
+// hourlyCostFor matches cons against pricing, preferring an exact
+// instance-type match and falling back to the (cores, mem, root-disk)
+// resource tuple. It returns 0 if nothing matches.
+func hourlyCostFor(pricing []pricingEntry, cons constraints.Value) float64 {
+	if cons.InstanceType != nil {
+		for _, p := range pricing {
+			if p.InstanceType != "" && p.InstanceType == *cons.InstanceType {
+				return p.HourlyCost
+			}
+		}
+	}
+
+	var cores, mem, rootDisk uint64
+	if cons.CpuCores != nil {
+		cores = *cons.CpuCores
+	}
+	if cons.Mem != nil {
+		mem = *cons.Mem
+	}
+	if cons.RootDisk != nil {
+		rootDisk = *cons.RootDisk
+	}
+	for _, p := range pricing {
+		if p.InstanceType == "" && p.Cores == cores && p.MemMB == mem && p.RootDiskMB == rootDisk {
+			return p.HourlyCost
+		}
+	}
+	return 0
+}
+
+// jujuPricingModel implements cloudprovider.PricingModel by looking a node up
+// in the provider's node groups and charging its precomputed hourly cost for
+// the requested time range.
+type jujuPricingModel struct {
+	provider *jujuCloudProvider
+}
+
+// NodePrice returns node's cost between startTime and endTime.
+func (p *jujuPricingModel) NodePrice(node *apiv1.Node, startTime, endTime time.Time) (float64, error) {
+	ng := p.nodeGroupFor(node)
+	if ng == nil {
+		return 0, nil
+	}
+	return ng.hourlyCost * endTime.Sub(startTime).Hours(), nil
+}
+
+// PodPrice is unimplemented: Juju node groups are priced per machine, not per
+// pod.
+func (p *jujuPricingModel) PodPrice(pod *apiv1.Pod, startTime, endTime time.Time) (float64, error) {
+	return 0, nil
+}
+
+// nodeGroupFor finds the NodeGroup backing node, first by the Juju node
+// group ID label (set on theoretical template nodes, which never have a
+// ProviderID) and falling back to ProviderID for real nodes.
+func (p *jujuPricingModel) nodeGroupFor(node *apiv1.Node) *NodeGroup {
+	if id, ok := node.Labels[nodeGroupIDLabel]; ok {
+		for _, ng := range p.provider.nodeGroups {
+			if jujuNG, ok := ng.(*NodeGroup); ok && jujuNG.id == id {
+				return jujuNG
+			}
+		}
+		return nil
+	}
+
+	for _, ng := range p.provider.nodeGroups {
+		jujuNG, ok := ng.(*NodeGroup)
+		if !ok {
+			continue
+		}
+		nodes, err := jujuNG.Nodes()
+		if err != nil {
+			continue
+		}
+		for _, n := range nodes {
+			if n.Id == node.Spec.ProviderID {
+				return jujuNG
+			}
+		}
+	}
+	return nil
+}
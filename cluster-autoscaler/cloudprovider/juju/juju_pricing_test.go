@@ -0,0 +1,100 @@
+package juju
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juju/juju/core/constraints"
+	"github.com/juju/juju/rpc/params"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+func strPtr(s string) *string { return &s }
+func u64Ptr(u uint64) *uint64 { return &u }
+
+func TestGPUTypeFromTagsFindsGPUTag(t *testing.T) {
+	tags := []string{"foo=bar", "gpu=nvidia-tesla-k80"}
+	gpuType, ok := gpuTypeFromTags(constraints.Value{Tags: &tags})
+	if !ok || gpuType != "nvidia-tesla-k80" {
+		t.Fatalf("gpuTypeFromTags() = (%q, %v), want (\"nvidia-tesla-k80\", true)", gpuType, ok)
+	}
+}
+
+func TestGPUTypeFromTagsNoGPUTag(t *testing.T) {
+	tags := []string{"foo=bar"}
+	if _, ok := gpuTypeFromTags(constraints.Value{Tags: &tags}); ok {
+		t.Fatalf("expected no GPU type without a gpu= tag")
+	}
+}
+
+func TestHourlyCostForMatchesInstanceType(t *testing.T) {
+	pricing := []pricingEntry{
+		{InstanceType: "m5.large", HourlyCost: 0.096},
+		{Cores: 2, MemMB: 4096, HourlyCost: 0.05},
+	}
+	cost := hourlyCostFor(pricing, constraints.Value{InstanceType: strPtr("m5.large")})
+	if cost != 0.096 {
+		t.Fatalf("hourlyCostFor() = %v, want 0.096", cost)
+	}
+}
+
+func TestHourlyCostForFallsBackToResourceTuple(t *testing.T) {
+	pricing := []pricingEntry{
+		{Cores: 2, MemMB: 4096, RootDiskMB: 8192, HourlyCost: 0.05},
+	}
+	cost := hourlyCostFor(pricing, constraints.Value{
+		CpuCores: u64Ptr(2),
+		Mem:      u64Ptr(4096),
+		RootDisk: u64Ptr(8192),
+	})
+	if cost != 0.05 {
+		t.Fatalf("hourlyCostFor() = %v, want 0.05", cost)
+	}
+}
+
+func TestHourlyCostForNoMatchReturnsZero(t *testing.T) {
+	pricing := []pricingEntry{{InstanceType: "m5.large", HourlyCost: 0.096}}
+	cost := hourlyCostFor(pricing, constraints.Value{InstanceType: strPtr("m5.xlarge")})
+	if cost != 0 {
+		t.Fatalf("hourlyCostFor() = %v, want 0", cost)
+	}
+}
+
+func TestNodePriceUsesNodeGroupHourlyCost(t *testing.T) {
+	client := &fakeJujuClient{status: &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}}}
+	m := newTestManager(t, client)
+	ng := &NodeGroup{id: "workers", application: "workers", manager: m, hourlyCost: 0.1}
+	provider, err := newJujuCloudProvider(nil, []cloudprovider.NodeGroup{ng})
+	if err != nil {
+		t.Fatalf("newJujuCloudProvider() returned error: %v", err)
+	}
+	model := &jujuPricingModel{provider: provider}
+
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{nodeGroupIDLabel: "workers"}}}
+	start := time.Unix(0, 0)
+	price, err := model.NodePrice(node, start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("NodePrice() returned error: %v", err)
+	}
+	if price != 0.2 {
+		t.Fatalf("NodePrice() = %v, want 0.2", price)
+	}
+}
+
+func TestNodePriceUnknownNodeReturnsZero(t *testing.T) {
+	provider, err := newJujuCloudProvider(nil, nil)
+	if err != nil {
+		t.Fatalf("newJujuCloudProvider() returned error: %v", err)
+	}
+	model := &jujuPricingModel{provider: provider}
+
+	price, err := model.NodePrice(&apiv1.Node{}, time.Unix(0, 0), time.Unix(3600, 0))
+	if err != nil {
+		t.Fatalf("NodePrice() returned error: %v", err)
+	}
+	if price != 0 {
+		t.Fatalf("NodePrice() = %v, want 0 for a node not belonging to any node group", price)
+	}
+}
@@ -0,0 +1,142 @@
+package juju
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/juju/clock"
+	jujuerrors "github.com/juju/errors"
+	"github.com/juju/retry"
+)
+
+// RetryStrategy configures how JujuAPI retries a transient RPC/connection
+// error from an applicationClient/statusClient call (e.g. a brief controller
+// restart), so a momentary hiccup doesn't fail an autoscaler loop and drop
+// state. Errors that aren't classified as transient by isTransientAPIError
+// (unit not found, invalid constraints, and other semantic API errors) are
+// returned immediately without retrying.
+type RetryStrategy struct {
+	// Attempts is the maximum number of attempts before giving up.
+	Attempts int
+
+	// Delay is the delay before the first retry.
+	Delay time.Duration
+
+	// MaxDelay caps the delay once BackoffFactor has grown it.
+	MaxDelay time.Duration
+
+	// BackoffFactor is multiplied into the delay after each failed attempt;
+	// 2.0 doubles it every time. A zero value disables backoff, retrying at
+	// a constant Delay.
+	BackoffFactor float64
+
+	// Jitter randomizes each backoff delay by up to +/- this fraction of
+	// itself (0.0-1.0). It applies only when BackoffFactor > 0, and never to
+	// the first Delay, so that many clients reconnecting after the same
+	// controller restart don't all retry in lockstep.
+	Jitter float64
+
+	// MaxDuration bounds the total time spent across all attempts, on top
+	// of Attempts.
+	MaxDuration time.Duration
+}
+
+// DefaultRetryStrategy is the strategy NewJujuAPi uses when the caller
+// doesn't need a custom one: up to 5 attempts over at most 2 minutes,
+// starting at a 1s delay and backing off to 30s.
+func DefaultRetryStrategy() RetryStrategy {
+	return RetryStrategy{
+		Attempts:      5,
+		Delay:         time.Second,
+		MaxDelay:      30 * time.Second,
+		BackoffFactor: 2,
+		Jitter:        0.1,
+		MaxDuration:   2 * time.Minute,
+	}
+}
+
+// call runs f, retrying it according to s until it succeeds, a non-transient
+// error is returned, or the strategy is exhausted. A non-transient error is
+// propagated as-is; an error that exhausted retries because it kept looking
+// transient is wrapped in ErrTransientAPI so callers can classify it with
+// IsTransient instead of matching on its text.
+func (s RetryStrategy) call(f func() error) error {
+	args := retry.CallArgs{
+		Func:         f,
+		IsFatalError: func(err error) bool { return !isTransientAPIError(err) },
+		Attempts:     s.Attempts,
+		Delay:        s.Delay,
+		MaxDelay:     s.MaxDelay,
+		MaxDuration:  s.MaxDuration,
+		Clock:        clock.WallClock,
+	}
+	if args.Delay == 0 {
+		// retry.Call requires a non-zero Delay; tests that want immediate
+		// retries set Delay to 0, so substitute the smallest possible wait.
+		args.Delay = time.Nanosecond
+	}
+	if s.BackoffFactor > 0 {
+		args.BackoffFunc = func(delay time.Duration, attempt int) time.Duration {
+			next := time.Duration(float64(delay) * s.BackoffFactor)
+			if s.Jitter > 0 {
+				next = jitter(next, s.Jitter)
+			}
+			return next
+		}
+	}
+	if err := retry.Call(args); err != nil {
+		// retry.Call only wraps err in one of its own exhausted/stopped types
+		// once it stops retrying on its own; when IsFatalError trips first,
+		// Call returns the original error (traced, not wrapped), so
+		// retry.LastError must not be called on it or it fabricates an
+		// "unexpected error type" error and discards the original identity.
+		if !retry.IsAttemptsExceeded(err) && !retry.IsDurationExceeded(err) && !retry.IsRetryStopped(err) {
+			return jujuerrors.Cause(err)
+		}
+		lastErr := retry.LastError(err)
+		if isTransientAPIError(lastErr) {
+			return fmt.Errorf("%w: %v", ErrTransientAPI, lastErr)
+		}
+		return lastErr
+	}
+	return nil
+}
+
+// jitter randomizes d by up to +/- fraction of itself.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	delta := float64(d) * fraction
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
+
+// isTransientAPIError reports whether err looks like a connection-level
+// failure (reset, EOF, the controller tearing down the API connection)
+// rather than a semantic error returned by the Juju API itself.
+func isTransientAPIError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := err.Error()
+	for _, substr := range []string{
+		"connection reset",
+		"connection refused",
+		"broken pipe",
+		"EOF",
+		"connection is shut down",
+		"use of closed network connection",
+	} {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
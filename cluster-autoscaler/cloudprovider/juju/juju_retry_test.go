@@ -0,0 +1,93 @@
+package juju
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// zeroDelayStrategy retries immediately, with no real waiting, so tests
+// don't have to sleep through backoff delays.
+func zeroDelayStrategy(attempts int) RetryStrategy {
+	return RetryStrategy{Attempts: attempts, Delay: 0, BackoffFactor: 0}
+}
+
+func TestRetryStrategyRetriesTransientErrorsUntilSuccess(t *testing.T) {
+	strategy := zeroDelayStrategy(3)
+	var calls int
+	err := strategy.call(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("write tcp 10.0.0.1:54321: connection reset by peer")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("call() returned error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3 (2 failures then a success)", calls)
+	}
+}
+
+func TestRetryStrategyGivesUpAfterAttemptsExhausted(t *testing.T) {
+	strategy := zeroDelayStrategy(2)
+	var calls int
+	wantErr := errors.New("EOF")
+	err := strategy.call(func() error {
+		calls++
+		return wantErr
+	})
+	if !IsTransient(err) {
+		t.Fatalf("IsTransient(%v) = false, want true once a transient error exhausts its attempts", err)
+	}
+	if !strings.Contains(err.Error(), wantErr.Error()) {
+		t.Fatalf("expected the wrapped error to still mention %q, got %q", wantErr, err)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want exactly 2 (Attempts)", calls)
+	}
+}
+
+func TestRetryStrategyShortCircuitsOnNonTransientError(t *testing.T) {
+	strategy := zeroDelayStrategy(5)
+	var calls int
+	wantErr := errors.New(`unit "workers/0" not found`)
+	err := strategy.call(func() error {
+		calls++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected the non-transient error to be returned as-is, got %v", err)
+	}
+	if IsTransient(err) {
+		t.Fatalf("IsTransient(%v) = true, want false for a semantic error", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, want exactly 1 (no retries for a semantic error)", calls)
+	}
+}
+
+func TestIsTransientAPIError(t *testing.T) {
+	transient := []string{
+		"read tcp 10.0.0.1:54321: connection reset by peer",
+		"unexpected EOF",
+		"connection is shut down",
+		"use of closed network connection",
+	}
+	for _, msg := range transient {
+		if !isTransientAPIError(errors.New(msg)) {
+			t.Errorf("isTransientAPIError(%q) = false, want true", msg)
+		}
+	}
+
+	notTransient := []string{
+		`unit "workers/0" not found`,
+		"invalid constraints: bad mem value",
+	}
+	for _, msg := range notTransient {
+		if isTransientAPIError(errors.New(msg)) {
+			t.Errorf("isTransientAPIError(%q) = true, want false", msg)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package juju
+
+import (
+	ctx "context"
+	"encoding/json"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	kube_client "k8s.io/client-go/kubernetes"
+	klog "k8s.io/klog/v2"
+)
+
+// UnitState is the persisted record of a single unit's in-flight
+// provisioning state, so a restarted autoscaler can recover units that were
+// mid-InstanceCreating or mid-InstanceDeleting instead of treating them as
+// already-settled.
+type UnitState struct {
+	State       cloudprovider.InstanceState `json:"state"`
+	Since       time.Time                   `json:"since"`
+	NodeGroupID string                      `json:"nodeGroupID"`
+}
+
+// stateStore persists UnitState entries, keyed by unit name, in a single
+// ConfigMap shared by every Manager in the process.
+type stateStore struct {
+	kubeClient kube_client.Interface
+	namespace  string
+	name       string
+	// entryTTL bounds how old a persisted entry can be before load() treats
+	// it as an abandoned provisioning attempt and drops it, rather than
+	// reconciling it into the recovered unit set.
+	entryTTL time.Duration
+}
+
+func newStateStore(kubeClient kube_client.Interface, namespace, name string, entryTTL time.Duration) *stateStore {
+	return &stateStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		entryTTL:   entryTTL,
+	}
+}
+
+// load returns the persisted, non-stale state for every unit belonging to
+// nodeGroupID.
+func (s *stateStore) load(nodeGroupID string) (map[string]UnitState, error) {
+	cm, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]UnitState)
+	for unitName, raw := range cm.Data {
+		var st UnitState
+		if err := json.Unmarshal([]byte(raw), &st); err != nil {
+			klog.Warningf("ignoring unparsable Juju state entry for unit %s: %v", unitName, err)
+			continue
+		}
+		if st.NodeGroupID != nodeGroupID {
+			continue
+		}
+		if s.entryTTL > 0 && time.Since(st.Since) > s.entryTTL {
+			klog.Infof("dropping stale Juju state entry for unit %s, last updated %s ago", unitName, time.Since(st.Since))
+			continue
+		}
+		states[unitName] = st
+	}
+	return states, nil
+}
+
+// save write-through persists unitName's current state.
+func (s *stateStore) save(unitName string, st UnitState) error {
+	encoded, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.update(func(data map[string]string) {
+		data[unitName] = string(encoded)
+	})
+}
+
+// delete removes unitName's persisted state, e.g. once it has actually been
+// destroyed.
+func (s *stateStore) delete(unitName string) error {
+	return s.update(func(data map[string]string) {
+		delete(data, unitName)
+	})
+}
+
+func (s *stateStore) get() (*apiv1.ConfigMap, error) {
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(ctx.TODO(), s.name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &apiv1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{},
+		}, nil
+	}
+	return cm, err
+}
+
+func (s *stateStore) update(mutate func(data map[string]string)) error {
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(ctx.TODO(), s.name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &apiv1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{},
+		}
+		mutate(cm.Data)
+		_, err = s.kubeClient.CoreV1().ConfigMaps(s.namespace).Create(ctx.TODO(), cm, v1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	mutate(cm.Data)
+
+	_, err = s.kubeClient.CoreV1().ConfigMaps(s.namespace).Update(ctx.TODO(), cm, v1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,125 @@
+package juju
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juju/juju/rpc/params"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	kube_fake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStateStoreSaveLoadRoundTrips(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	store := newStateStore(kubeClient, "kube-system", "cluster-autoscaler-juju-state", time.Hour)
+
+	if err := store.save("workers/0", UnitState{State: cloudprovider.InstanceCreating, Since: time.Now(), NodeGroupID: "juju-ctrl-model-workers"}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	states, err := store.load("juju-ctrl-model-workers")
+	if err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	st, ok := states["workers/0"]
+	if !ok {
+		t.Fatalf("expected workers/0 to be present in loaded state")
+	}
+	if st.State != cloudprovider.InstanceCreating {
+		t.Fatalf("got state %v, want InstanceCreating", st.State)
+	}
+}
+
+func TestStateStoreLoadFiltersByNodeGroupID(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	store := newStateStore(kubeClient, "kube-system", "cluster-autoscaler-juju-state", time.Hour)
+
+	if err := store.save("workers/0", UnitState{State: cloudprovider.InstanceRunning, Since: time.Now(), NodeGroupID: "juju-ctrl-model-workers"}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+	if err := store.save("other/0", UnitState{State: cloudprovider.InstanceRunning, Since: time.Now(), NodeGroupID: "juju-ctrl-model-other"}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	states, err := store.load("juju-ctrl-model-workers")
+	if err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	if len(states) != 1 {
+		t.Fatalf("expected exactly 1 entry for the requested node group, got %d", len(states))
+	}
+	if _, ok := states["workers/0"]; !ok {
+		t.Fatalf("expected workers/0 to be present in loaded state")
+	}
+}
+
+func TestStateStoreLoadDropsStaleEntries(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	store := newStateStore(kubeClient, "kube-system", "cluster-autoscaler-juju-state", time.Minute)
+
+	stale := UnitState{State: cloudprovider.InstanceCreating, Since: time.Now().Add(-time.Hour), NodeGroupID: "juju-ctrl-model-workers"}
+	if err := store.save("workers/0", stale); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	states, err := store.load("juju-ctrl-model-workers")
+	if err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	if len(states) != 0 {
+		t.Fatalf("expected stale entry to be dropped, got %d entries", len(states))
+	}
+}
+
+func TestStateStoreDeleteRemovesEntry(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	store := newStateStore(kubeClient, "kube-system", "cluster-autoscaler-juju-state", time.Hour)
+
+	if err := store.save("workers/0", UnitState{State: cloudprovider.InstanceDeleting, Since: time.Now(), NodeGroupID: "juju-ctrl-model-workers"}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+	if err := store.delete("workers/0"); err != nil {
+		t.Fatalf("delete() returned error: %v", err)
+	}
+
+	states, err := store.load("juju-ctrl-model-workers")
+	if err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	if len(states) != 0 {
+		t.Fatalf("expected deleted entry to be absent, got %d entries", len(states))
+	}
+}
+
+func TestNewManagerRecoversInstanceDeletingFromPersistedState(t *testing.T) {
+	kubeClient := kube_fake.NewSimpleClientset()
+	store := newStateStore(kubeClient, "kube-system", "cluster-autoscaler-juju-state", time.Hour)
+	if err := store.save("workers/0", UnitState{State: cloudprovider.InstanceDeleting, Since: time.Now(), NodeGroupID: "juju-ctrl-model-workers"}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	client := &fakeJujuClient{
+		status: &params.FullStatus{
+			Applications: map[string]params.ApplicationStatus{
+				"workers": {Units: map[string]params.UnitStatus{
+					// Still reported as active/idle by Juju, but the persisted
+					// state says removeUnit was already mid-flight for it.
+					"workers/0": {WorkloadStatus: params.DetailedStatus{Status: "active"}, AgentStatus: params.DetailedStatus{Status: "idle"}},
+				}},
+			},
+		},
+	}
+
+	m := NewManager(client, kubeClient, "model-1", DrainOptions{}, store)
+	if err := m.AddApplication("workers", 0, 0, "juju-ctrl-model-workers", "", nil, DestroyPolicy{}); err != nil {
+		t.Fatalf("AddApplication() returned error: %v", err)
+	}
+
+	unit, ok := m.apps["workers"].units["workers/0"]
+	if !ok {
+		t.Fatalf("expected workers/0 to be recovered into managed units")
+	}
+	if unit.state != cloudprovider.InstanceDeleting {
+		t.Fatalf("got state %v, want recovered state InstanceDeleting", unit.state)
+	}
+}
@@ -0,0 +1,136 @@
+package juju
+
+import (
+	"strings"
+
+	"github.com/juju/juju/core/constraints"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/topology"
+)
+
+// zoneTagPrefix marks a Juju constraints tag as naming one of the NUMA zones
+// this node group's units should be spread across, e.g. "zone=numa0".
+const zoneTagPrefix = "zone="
+
+var _ topology.TemplateProvider = (*NodeGroup)(nil)
+
+// TopologyStateForTemplate synthesizes the per-zone TopologyState for the
+// template node TemplateNodeInfo would create, satisfying
+// topology.TemplateProvider: one zone per "zone=<name>" constraints tag,
+// each given the application's full cpu/mem constraints as that zone's
+// capacity. A node group with no zone tags has no topology information to
+// offer, matching TemplateProvider's documented flat-capacity fallback.
+func (n *NodeGroup) TopologyStateForTemplate() (*topology.TopologyState, bool) {
+	cons := n.manager.Constraints(n.application)
+	if cons.Tags == nil {
+		return nil, false
+	}
+
+	zones := make(map[string]*topology.Zone)
+	for _, tag := range *cons.Tags {
+		if !strings.HasPrefix(tag, zoneTagPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(tag, zoneTagPrefix)
+		zones[name] = &topology.Zone{Name: name, Resources: zoneResourcesFromConstraints(cons)}
+	}
+	if len(zones) == 0 {
+		return nil, false
+	}
+
+	return &topology.TopologyState{
+		NodeName: n.id,
+		Policy:   topology.PolicyBestEffort,
+		Zones:    zones,
+	}, true
+}
+
+func zoneResourcesFromConstraints(cons constraints.Value) topology.ZoneResources {
+	resources := topology.ZoneResources{}
+	if cons.CpuCores != nil {
+		resources[apiv1.ResourceCPU] = *resource.NewQuantity(int64(*cons.CpuCores), resource.DecimalSI)
+	}
+	if cons.Mem != nil {
+		resources[apiv1.ResourceMemory] = *resource.NewQuantity(int64(*cons.Mem)*1024*1024, resource.BinarySI)
+	}
+	return resources
+}
+
+// topologyStore holds the per-node-group NUMA/zone state FitsTopology checks
+// real (non-template) nodes against, kept current by UpdateNodeTopology.
+// There is no NodeResourceTopology CRD client/informer vendored in this
+// tree to drive it automatically, so it's populated by whatever external watcher a
+// deployment wires up to call UpdateNodeTopology, and otherwise stays empty.
+//
+// UpdateNodeTopology records nrt's topology state, making it available to
+// FitsTopology. nrt.NodeName is expected to carry the reporting node's node
+// group ID rather than its raw hostname: a Juju node group's units all share
+// one application's machine constraints, the same homogeneous-group
+// assumption jujuPricingModel and GetAvailableGPUTypes already make for
+// hourlyCost and gpuType, so one reported NodeResourceTopology per group is
+// enough to cover every unit in it.
+func (j *jujuCloudProvider) UpdateNodeTopology(nrt *topology.NodeResourceTopology) {
+	if j.topologyStore == nil {
+		j.topologyStore = topology.NewStore()
+	}
+	j.topologyStore.Update(nrt)
+}
+
+// FitsTopology reports whether pod's resource requests can be admitted onto
+// a node in the node group identified by nodeGroupID without violating its
+// topology manager policy. It checks the node group's real, currently
+// reported state from the topology store first (see UpdateNodeTopology),
+// falling back to its synthesized template state (TopologyStateForTemplate)
+// for a node group with no real reported state yet, e.g. one that hasn't
+// scaled up. A node group with neither returns true: no topology information
+// means no topology constraint.
+//
+// This is the fit-check a scale-up estimator runs before trusting a
+// candidate node's claimed capacity; this snapshot has no estimator package
+// to call it from (cloudprovider/simulator/estimator isn't vendored here),
+// so it's exercised directly by its own tests.
+func (j *jujuCloudProvider) FitsTopology(nodeGroupID string, pod *apiv1.Pod) bool {
+	var ng *NodeGroup
+	for _, candidate := range j.nodeGroups {
+		if jujuNG, ok := candidate.(*NodeGroup); ok && jujuNG.id == nodeGroupID {
+			ng = jujuNG
+			break
+		}
+	}
+	if ng == nil {
+		return true
+	}
+
+	var state *topology.TopologyState
+	if j.topologyStore != nil {
+		state = j.topologyStore.Get(ng.id)
+	}
+	if state == nil {
+		var ok bool
+		state, ok = ng.TopologyStateForTemplate()
+		if !ok {
+			return true
+		}
+	}
+
+	hint := topology.PodTopologyHint{
+		Policy:   state.Policy,
+		Requests: podResourceRequests(pod),
+	}
+	return topology.NewFilter().FitsTopology(state, hint)
+}
+
+// podResourceRequests sums pod's container resource requests, the same
+// aggregate FitsTopology's caller would otherwise have to compute per pod.
+func podResourceRequests(pod *apiv1.Pod) apiv1.ResourceList {
+	total := apiv1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for rn, q := range c.Resources.Requests {
+			sum := total[rn]
+			sum.Add(q)
+			total[rn] = sum
+		}
+	}
+	return total
+}
@@ -0,0 +1,116 @@
+package juju
+
+import (
+	"testing"
+
+	"github.com/juju/juju/core/constraints"
+	"github.com/juju/juju/rpc/params"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator/topology"
+)
+
+func newTestNodeGroup(t *testing.T, cons constraints.Value) *NodeGroup {
+	t.Helper()
+	client := &fakeJujuClient{
+		status:      &params.FullStatus{Applications: map[string]params.ApplicationStatus{"workers": {Units: map[string]params.UnitStatus{}}}},
+		constraints: cons,
+	}
+	m := newTestManager(t, client)
+	return &NodeGroup{id: "workers", application: "workers", manager: m}
+}
+
+func TestTopologyStateForTemplateDerivesZonesFromTags(t *testing.T) {
+	tags := []string{"zone=numa0", "gpu=nvidia-tesla-k80"}
+	cpu := uint64(4)
+	mem := uint64(8192)
+	ng := newTestNodeGroup(t, constraints.Value{Tags: &tags, CpuCores: &cpu, Mem: &mem})
+
+	state, ok := ng.TopologyStateForTemplate()
+	if !ok {
+		t.Fatalf("expected a topology state for a node group with a zone tag")
+	}
+	if state.Policy != topology.PolicyBestEffort {
+		t.Fatalf("got policy %v, want PolicyBestEffort", state.Policy)
+	}
+	zone, ok := state.Zones["numa0"]
+	if !ok {
+		t.Fatalf("expected a zone named numa0, got %v", state.Zones)
+	}
+	if got := zone.Resources[apiv1.ResourceCPU]; got.Cmp(*resource.NewQuantity(4, resource.DecimalSI)) != 0 {
+		t.Fatalf("got cpu %v, want 4", got)
+	}
+}
+
+func TestTopologyStateForTemplateNoZoneTagReturnsFalse(t *testing.T) {
+	tags := []string{"gpu=nvidia-tesla-k80"}
+	ng := newTestNodeGroup(t, constraints.Value{Tags: &tags})
+
+	if _, ok := ng.TopologyStateForTemplate(); ok {
+		t.Fatalf("expected no topology state for a node group with no zone= tag")
+	}
+}
+
+func podWithCPURequest(cpu string) *apiv1.Pod {
+	return &apiv1.Pod{Spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+		Resources: apiv1.ResourceRequirements{Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu)}},
+	}}}}
+}
+
+func TestFitsTopologyUnknownNodeGroupAlwaysFits(t *testing.T) {
+	j := &jujuCloudProvider{}
+	if !j.FitsTopology("missing", podWithCPURequest("100")) {
+		t.Fatalf("expected a node group this provider doesn't know about to always fit")
+	}
+}
+
+func TestFitsTopologyNoTopologyInfoAlwaysFits(t *testing.T) {
+	ng := newTestNodeGroup(t, constraints.Value{})
+	j := &jujuCloudProvider{nodeGroups: []cloudprovider.NodeGroup{ng}}
+
+	if !j.FitsTopology("workers", podWithCPURequest("100")) {
+		t.Fatalf("expected a node group with no topology information to always fit")
+	}
+}
+
+func TestFitsTopologyFallsBackToTemplateState(t *testing.T) {
+	// TopologyStateForTemplate always derives PolicyBestEffort (there is no
+	// richer policy in Juju machine constraints to derive from), so a
+	// template-only fit check always admits, matching best-effort's
+	// documented fallback-to-spreading semantics in Filter.FitsTopology.
+	tags := []string{"zone=numa0"}
+	cpu := uint64(2)
+	ng := newTestNodeGroup(t, constraints.Value{Tags: &tags, CpuCores: &cpu})
+	j := &jujuCloudProvider{nodeGroups: []cloudprovider.NodeGroup{ng}}
+
+	if !j.FitsTopology("workers", podWithCPURequest("100")) {
+		t.Fatalf("expected best-effort template state to admit even a request exceeding the zone's capacity")
+	}
+}
+
+func TestFitsTopologyPrefersReportedStateOverTemplate(t *testing.T) {
+	// The reported state carries a stricter SingleNUMANode policy than the
+	// node group's template (always PolicyBestEffort), so a fit check that
+	// consults the reported state must reject a request the template alone
+	// would have admitted.
+	tags := []string{"zone=numa0"}
+	cpu := uint64(100)
+	ng := newTestNodeGroup(t, constraints.Value{Tags: &tags, CpuCores: &cpu})
+	j := &jujuCloudProvider{nodeGroups: []cloudprovider.NodeGroup{ng}}
+
+	j.UpdateNodeTopology(&topology.NodeResourceTopology{
+		NodeName:         "workers",
+		TopologyPolicies: []string{string(topology.PolicySingleNUMANode)},
+		Zones: []topology.NRTZone{
+			{Name: "numa0", Resources: map[apiv1.ResourceName]resource.Quantity{apiv1.ResourceCPU: resource.MustParse("2")}},
+		},
+	})
+
+	if !j.FitsTopology("workers", podWithCPURequest("1")) {
+		t.Fatalf("expected pod requesting less than the reported zone's capacity to fit")
+	}
+	if j.FitsTopology("workers", podWithCPURequest("3")) {
+		t.Fatalf("expected the reported state's single-numa-node policy, not the template's best-effort, to govern the fit check")
+	}
+}
@@ -0,0 +1,108 @@
+package topology
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// PodTopologyHint is the topology-relevant subset of a pod spec: the
+// requests that must be satisfied within the constraints of Policy. It
+// carries no record of resources already placed on the node by other pods.
+type PodTopologyHint struct {
+	Policy   Policy
+	Requests apiv1.ResourceList
+}
+
+// HasHint reports whether a pod carries any topology requirement at all. A
+// pod with no hint must never be over-constrained by this filter; it is
+// always admitted regardless of TopologyState.
+func (h PodTopologyHint) HasHint() bool {
+	return h.Policy != "" && h.Policy != PolicyNone && len(h.Requests) > 0
+}
+
+// Filter decides whether a node (real or template) with the given
+// TopologyState can admit a pod with the given topology hint. The zone
+// resources in the state are used as given; pods already placed on the node
+// are not subtracted from them.
+type Filter struct{}
+
+// NewFilter returns a ready-to-use topology Filter.
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// FitsTopology returns true if at least one zone (or, for PolicyRestricted, a
+// contiguous set of neighboring zones) of state can satisfy hint.Requests;
+// under PolicyBestEffort it always returns true. A nil state or a pod without a topology hint always fits: nodes that don't
+// report NodeResourceTopology, and pods with no topology requirement, fall
+// back to the existing flat-capacity behavior.
+func (f *Filter) FitsTopology(state *TopologyState, hint PodTopologyHint) bool {
+	if state == nil || !hint.HasHint() {
+		return true
+	}
+
+	switch hint.Policy {
+	case PolicyRestricted:
+		return f.fitsContiguous(state, hint.Requests)
+	case PolicyBestEffort:
+		// best-effort tries to align to a single zone, but unlike
+		// single-numa-node it's not a hard requirement: a node that can't
+		// satisfy the request from one zone is still admitted, since the
+		// scheduler falls back to spreading the request across zones.
+		return true
+	default:
+		// single-numa-node requires a single zone to satisfy the request.
+		return f.fitsSingleZone(state, hint.Requests) != ""
+	}
+}
+
+// fitsSingleZone returns the name of the first zone able to satisfy
+// requests on its own, or "" if none can.
+func (f *Filter) fitsSingleZone(state *TopologyState, requests apiv1.ResourceList) string {
+	for name, zone := range state.Zones {
+		if zoneSatisfies(zone.Resources, requests) {
+			return name
+		}
+	}
+	return ""
+}
+
+// fitsContiguous checks whether requests can be satisfied by a single zone
+// or by summing a zone with its directly neighboring zones, as allowed by
+// the `restricted` topology manager policy.
+func (f *Filter) fitsContiguous(state *TopologyState, requests apiv1.ResourceList) bool {
+	if f.fitsSingleZone(state, requests) != "" {
+		return true
+	}
+	for name, zone := range state.Zones {
+		combined := make(ZoneResources, len(zone.Resources))
+		for rn, q := range zone.Resources {
+			combined[rn] = q.DeepCopy()
+		}
+		for _, neighbor := range zone.Neighbors {
+			neighborZone, ok := state.Zones[neighbor]
+			if !ok {
+				continue
+			}
+			for rn, q := range neighborZone.Resources {
+				sum := combined[rn]
+				sum.Add(q)
+				combined[rn] = sum
+			}
+		}
+		if zoneSatisfies(combined, requests) {
+			return true
+		}
+		_ = name
+	}
+	return false
+}
+
+func zoneSatisfies(free ZoneResources, requests apiv1.ResourceList) bool {
+	for rn, want := range requests {
+		have, ok := free[rn]
+		if !ok || have.Cmp(want) < 0 {
+			return false
+		}
+	}
+	return true
+}
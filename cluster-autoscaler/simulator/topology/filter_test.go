@@ -0,0 +1,106 @@
+package topology
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func zone(name string, cpu, mem string, neighbors ...string) *Zone {
+	return &Zone{
+		Name: name,
+		Resources: ZoneResources{
+			apiv1.ResourceCPU:    resource.MustParse(cpu),
+			apiv1.ResourceMemory: resource.MustParse(mem),
+		},
+		Neighbors: neighbors,
+	}
+}
+
+func TestFitsTopologyNoHintAlwaysFits(t *testing.T) {
+	f := NewFilter()
+	state := &TopologyState{Policy: PolicySingleNUMANode, Zones: map[string]*Zone{"zone0": zone("zone0", "1", "1Gi")}}
+
+	if !f.FitsTopology(state, PodTopologyHint{}) {
+		t.Fatalf("pod with no topology hint must not be over-constrained")
+	}
+	if !f.FitsTopology(nil, PodTopologyHint{Policy: PolicySingleNUMANode, Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100")}}) {
+		t.Fatalf("node without NodeResourceTopology must fall back to flat capacity")
+	}
+}
+
+func TestFitsTopologySingleNUMANode(t *testing.T) {
+	f := NewFilter()
+	state := &TopologyState{
+		Policy: PolicySingleNUMANode,
+		Zones: map[string]*Zone{
+			"zone0": zone("zone0", "2", "2Gi"),
+			"zone1": zone("zone1", "4", "4Gi"),
+		},
+	}
+	hint := PodTopologyHint{
+		Policy: PolicySingleNUMANode,
+		Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("3"),
+			apiv1.ResourceMemory: resource.MustParse("3Gi"),
+		},
+	}
+
+	if !f.FitsTopology(state, hint) {
+		t.Fatalf("expected pod to fit in zone1 alone")
+	}
+
+	hint.Requests[apiv1.ResourceCPU] = resource.MustParse("5")
+	if f.FitsTopology(state, hint) {
+		t.Fatalf("expected pod requesting more cpu than any single zone to be rejected under single-numa-node")
+	}
+}
+
+func TestFitsTopologyBestEffortFallsBackWhenNoSingleZoneFits(t *testing.T) {
+	f := NewFilter()
+	state := &TopologyState{
+		Policy: PolicyBestEffort,
+		Zones: map[string]*Zone{
+			"zone0": zone("zone0", "2", "2Gi"),
+			"zone1": zone("zone1", "2", "2Gi"),
+		},
+	}
+	hint := PodTopologyHint{
+		Policy: PolicyBestEffort,
+		Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU: resource.MustParse("3"),
+		},
+	}
+
+	if !f.FitsTopology(state, hint) {
+		t.Fatalf("expected best-effort to fall back to spreading across zones instead of rejecting admission")
+	}
+}
+
+func TestFitsTopologyRestrictedAllowsContiguousZones(t *testing.T) {
+	f := NewFilter()
+	state := &TopologyState{
+		Policy: PolicyRestricted,
+		Zones: map[string]*Zone{
+			"zone0": zone("zone0", "2", "2Gi", "zone1"),
+			"zone1": zone("zone1", "2", "2Gi", "zone0"),
+		},
+	}
+	hint := PodTopologyHint{
+		Policy: PolicyRestricted,
+		Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse("3"),
+			apiv1.ResourceMemory: resource.MustParse("3Gi"),
+		},
+	}
+
+	if !f.FitsTopology(state, hint) {
+		t.Fatalf("expected pod to fit by combining neighboring zones under restricted policy")
+	}
+
+	hint.Requests[apiv1.ResourceCPU] = resource.MustParse("5")
+	if f.FitsTopology(state, hint) {
+		t.Fatalf("expected pod requesting more cpu than all zones combined to be rejected")
+	}
+}
@@ -0,0 +1,19 @@
+package topology
+
+// TemplateProvider lets a cloud provider's NodeGroup synthesize the per-zone
+// capacity of a not-yet-created node so the NUMA-aware fit check in Filter
+// can run against scale-up templates the same way it runs against real
+// nodes. A NodeGroup that doesn't implement this (or returns ok=false) is
+// treated as having no topology information, and template-node fit checks
+// fall back to the flat-capacity behavior.
+//
+// Cloud providers implement this by mapping their instance-type metadata
+// (sockets, cores-per-socket, memory-per-zone, attached device topology)
+// onto a TopologyState; they do not need to talk to the NodeResourceTopology
+// API themselves.
+type TemplateProvider interface {
+	// TopologyStateForTemplate returns the synthesized TopologyState for the
+	// template node this NodeGroup would create, or ok=false if the group
+	// has no topology information to offer.
+	TopologyStateForTemplate() (state *TopologyState, ok bool)
+}
@@ -0,0 +1,90 @@
+package topology
+
+import (
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NodeResourceTopology is the subset of the noderesourcetopology.k8s.io CRD
+// that the Store cares about. It intentionally mirrors the wire shape
+// published by node-feature-discovery / RTE rather than depending on their
+// generated clientset, so this package has no external CRD client
+// dependency; callers translate from whichever NRT client they already run.
+type NodeResourceTopology struct {
+	NodeName         string
+	TopologyPolicies []string
+	Zones            []NRTZone
+}
+
+// NRTZone is one zone entry of a NodeResourceTopology object.
+type NRTZone struct {
+	Name      string
+	Type      string
+	Resources map[apiv1.ResourceName]resource.Quantity
+	Neighbors []string
+}
+
+// Store holds the latest TopologyState seen for each node, kept up to date
+// by a caller-driven resync (typically an informer watching
+// NodeResourceTopology objects). It is safe for concurrent use.
+type Store struct {
+	mu     sync.RWMutex
+	states map[string]*TopologyState
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{states: make(map[string]*TopologyState)}
+}
+
+// Get returns the current TopologyState for nodeName, or nil if the node has
+// never reported a NodeResourceTopology object.
+func (s *Store) Get(nodeName string) *TopologyState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[nodeName].Clone()
+}
+
+// Update records (or replaces) the TopologyState derived from nrt. It is
+// called from the resync watcher on add/update events.
+func (s *Store) Update(nrt *NodeResourceTopology) {
+	state := &TopologyState{
+		NodeName: nrt.NodeName,
+		Policy:   policyFromNRT(nrt.TopologyPolicies),
+		Zones:    make(map[string]*Zone, len(nrt.Zones)),
+	}
+	for _, z := range nrt.Zones {
+		resources := make(ZoneResources, len(z.Resources))
+		for rn, q := range z.Resources {
+			resources[rn] = q.DeepCopy()
+		}
+		state.Zones[z.Name] = &Zone{
+			Name:      z.Name,
+			Resources: resources,
+			Neighbors: append([]string(nil), z.Neighbors...),
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[nrt.NodeName] = state
+}
+
+// Delete removes the TopologyState for nodeName, e.g. on node/NRT deletion.
+func (s *Store) Delete(nodeName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, nodeName)
+}
+
+func policyFromNRT(policies []string) Policy {
+	for _, p := range policies {
+		switch Policy(p) {
+		case PolicyRestricted, PolicySingleNUMANode, PolicyBestEffort:
+			return Policy(p)
+		}
+	}
+	return PolicyNone
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topology provides NUMA/zone-aware admission checks for the
+// binpacking simulator, backed by NodeResourceTopology CRDs as published
+// by node-feature-discovery / the resource-topology-exporter (RTE).
+package topology
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Policy is the topology manager policy in effect on a node, as reported by
+// the kubelet through the NodeResourceTopology object.
+type Policy string
+
+const (
+	// PolicyNone means the node does not constrain pod placement by zone.
+	PolicyNone Policy = "none"
+	// PolicyBestEffort tries to align to a single zone but falls back to
+	// spreading across zones when that isn't possible.
+	PolicyBestEffort Policy = "best-effort"
+	// PolicyRestricted requires alignment to a single zone, or a small set of
+	// zones, and fails admission rather than spread arbitrarily.
+	PolicyRestricted Policy = "restricted"
+	// PolicySingleNUMANode requires every resource of the pod to be
+	// satisfiable from exactly one zone.
+	PolicySingleNUMANode Policy = "single-numa-node"
+)
+
+// ZoneResources holds the free capacity of a single NUMA zone, keyed by
+// resource name (cpu, memory, hugepages-2Mi, or a device plugin resource).
+type ZoneResources map[apiv1.ResourceName]resource.Quantity
+
+// Zone describes one NUMA zone of a node: its free resources and the zones
+// it is directly interconnected with, which matters for the `restricted`
+// policy where a pod may be admitted onto a contiguous set of zones.
+type Zone struct {
+	Name      string
+	Resources ZoneResources
+	Neighbors []string
+}
+
+// TopologyState is the per-node view of zone-level resources, refreshed from
+// the node's NodeResourceTopology object. A node with no TopologyState is
+// treated as having no zone constraints (the flat-capacity behavior).
+type TopologyState struct {
+	NodeName string
+	Policy   Policy
+	Zones    map[string]*Zone
+}
+
+// Clone returns a deep copy of the TopologyState so callers can subtract
+// already-placed pods from a scratch copy without mutating the cached state.
+func (t *TopologyState) Clone() *TopologyState {
+	if t == nil {
+		return nil
+	}
+	clone := &TopologyState{
+		NodeName: t.NodeName,
+		Policy:   t.Policy,
+		Zones:    make(map[string]*Zone, len(t.Zones)),
+	}
+	for name, zone := range t.Zones {
+		resources := make(ZoneResources, len(zone.Resources))
+		for rn, q := range zone.Resources {
+			resources[rn] = q.DeepCopy()
+		}
+		clone.Zones[name] = &Zone{
+			Name:      zone.Name,
+			Resources: resources,
+			Neighbors: append([]string(nil), zone.Neighbors...),
+		}
+	}
+	return clone
+}
+
+// Subtract removes the resources already consumed by pod from the zone
+// named zoneName. It is a no-op if the zone is unknown.
+func (t *TopologyState) Subtract(zoneName string, pod apiv1.ResourceList) {
+	zone, ok := t.Zones[zoneName]
+	if !ok {
+		return
+	}
+	for rn, want := range pod {
+		have, ok := zone.Resources[rn]
+		if !ok {
+			continue
+		}
+		have.Sub(want)
+		zone.Resources[rn] = have
+	}
+}
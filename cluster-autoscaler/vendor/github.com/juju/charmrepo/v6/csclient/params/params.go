@@ -8,6 +8,8 @@ package params // import "github.com/juju/charmrepo/v6/csclient/params"
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/juju/charm/v8"
@@ -67,7 +69,10 @@ var OrderedChannels = []Channel{
 	UnpublishedChannel,
 }
 
-// ValidChannels holds the set of all allowed channels for an entity.
+// ValidChannels holds the set of all allowed risk levels for an entity.
+// A Channel may additionally carry a track and branch (see ParseChannel);
+// ValidChannels only ever needs to validate the risk component, since track
+// and branch names are free-form.
 var ValidChannels = func() map[Channel]bool {
 	channels := make(map[Channel]bool, len(OrderedChannels))
 	for _, ch := range OrderedChannels {
@@ -76,6 +81,94 @@ var ValidChannels = func() map[Channel]bool {
 	return channels
 }()
 
+// defaultTrack is the track assumed for a channel name that doesn't specify
+// one, matching Charmhub's convention.
+const defaultTrack = "latest"
+
+// ParseChannel parses s as a Charmhub-style channel name of the form
+// <track>/<risk>[/<branch>]. The legacy bare-risk form (e.g. "stable") is
+// also accepted, with the track defaulting to "latest". It returns an error
+// if s has too many components or its risk isn't one of ValidChannels.
+func ParseChannel(s string) (Channel, error) {
+	parts := strings.Split(s, "/")
+	var track, risk, branch string
+	switch len(parts) {
+	case 1:
+		track, risk = defaultTrack, parts[0]
+	case 2:
+		track, risk = parts[0], parts[1]
+	case 3:
+		track, risk, branch = parts[0], parts[1], parts[2]
+	default:
+		return "", fmt.Errorf("invalid channel %q: too many components", s)
+	}
+	if track == "" {
+		track = defaultTrack
+	}
+	if !ValidChannels[Channel(risk)] {
+		return "", fmt.Errorf("invalid channel %q: unknown risk %q", s, risk)
+	}
+
+	ch := Channel(risk)
+	// Only omit an explicit "latest" track when there's no branch: a branch
+	// segment always needs the track segment before it, or parts() would
+	// misread the branch as the risk and the risk as the track.
+	if track != defaultTrack || branch != "" {
+		ch = Channel(track) + "/" + ch
+	}
+	if branch != "" {
+		ch += Channel("/" + branch)
+	}
+	return ch, nil
+}
+
+// parts splits ch into its track, risk and branch components, defaulting an
+// omitted track to "latest".
+func (ch Channel) parts() (track, risk, branch string) {
+	parts := strings.SplitN(string(ch), "/", 3)
+	track = parts[0]
+	if len(parts) == 1 {
+		return defaultTrack, track, ""
+	}
+	if track == "" {
+		track = defaultTrack
+	}
+	risk = parts[1]
+	if len(parts) == 3 {
+		branch = parts[2]
+	}
+	return track, risk, branch
+}
+
+// Track returns ch's track component, defaulting to "latest" if ch doesn't
+// specify one.
+func (ch Channel) Track() string {
+	track, _, _ := ch.parts()
+	return track
+}
+
+// Risk returns ch's risk component.
+func (ch Channel) Risk() string {
+	_, risk, _ := ch.parts()
+	return risk
+}
+
+// Branch returns ch's branch component, or "" if ch doesn't have one.
+func (ch Channel) Branch() string {
+	_, _, branch := ch.parts()
+	return branch
+}
+
+// String returns ch in its fully-qualified <track>/<risk>[/<branch>] form.
+func (ch Channel) String() string {
+	track, risk, branch := ch.parts()
+	s := track + "/" + risk
+	if branch != "" {
+		s += "/" + branch
+	}
+	return s
+}
+
 // MetaAnyResponse holds the result of a meta/any request.
 // See https://github.com/juju/charmstore/blob/v5-unstable/docs/API.md#get-idmetaany
 type MetaAnyResponse EntityResult
@@ -171,6 +264,81 @@ type SupportedSeriesResponse struct {
 	SupportedSeries []string
 }
 
+// MetaSupportedBases is the name of the id/meta/supported-bases endpoint,
+// the bases-oriented counterpart to id/meta/supported-series.
+const MetaSupportedBases = "supported-bases"
+
+// Base holds a charm's compatibility as expressed by newer Juju/Charmhub
+// metadata: an OS name, a channel (typically the OS release version, e.g.
+// "22.04"), and the architectures the charm supports on that base.
+type Base struct {
+	Name          string
+	Channel       string
+	Architectures []string
+}
+
+// SupportedBasesResponse holds the result of an id/meta/supported-bases GET
+// request, the bases-oriented counterpart to SupportedSeriesResponse.
+type SupportedBasesResponse struct {
+	SupportedBases []Base
+}
+
+// ubuntuSeriesToRelease maps well-known Ubuntu series names to the release
+// version Charmhub uses as a base channel, so legacy series-only metadata
+// can be converted to the newer bases form and back.
+var ubuntuSeriesToRelease = map[string]string{
+	"precise": "12.04",
+	"trusty":  "14.04",
+	"xenial":  "16.04",
+	"bionic":  "18.04",
+	"focal":   "20.04",
+	"jammy":   "22.04",
+	"noble":   "24.04",
+}
+
+var ubuntuReleaseToSeries = func() map[string]string {
+	m := make(map[string]string, len(ubuntuSeriesToRelease))
+	for series, release := range ubuntuSeriesToRelease {
+		m[release] = series
+	}
+	return m
+}()
+
+// BasesFromSupportedSeries converts a legacy SupportedSeriesResponse into
+// the equivalent SupportedBasesResponse, using the well-known Ubuntu
+// series↔release mapping. Series without a known release are omitted.
+func BasesFromSupportedSeries(resp SupportedSeriesResponse) SupportedBasesResponse {
+	var bases SupportedBasesResponse
+	for _, series := range resp.SupportedSeries {
+		release, ok := ubuntuSeriesToRelease[series]
+		if !ok {
+			continue
+		}
+		bases.SupportedBases = append(bases.SupportedBases, Base{
+			Name:    "ubuntu",
+			Channel: release,
+		})
+	}
+	return bases
+}
+
+// SupportedSeriesFromBases converts a SupportedBasesResponse into the
+// equivalent legacy SupportedSeriesResponse, so callers that only understand
+// series can transparently consume bases-oriented metadata. Bases that
+// aren't Ubuntu, or whose channel isn't a known release, are omitted.
+func SupportedSeriesFromBases(resp SupportedBasesResponse) SupportedSeriesResponse {
+	var series SupportedSeriesResponse
+	for _, base := range resp.SupportedBases {
+		if base.Name != "ubuntu" {
+			continue
+		}
+		if s, ok := ubuntuReleaseToSeries[base.Channel]; ok {
+			series.SupportedSeries = append(series.SupportedSeries, s)
+		}
+	}
+	return series
+}
+
 // BundleCount holds the result of an id/meta/bundle-unit-count
 // or bundle-machine-count GET request.
 // See https://github.com/juju/charmstore/blob/v5-unstable/docs/API.md#get-idmetabundle-unit-count
@@ -379,6 +547,12 @@ type Resource struct {
 
 	// Size is the size of the resource, in bytes.
 	Size int64
+
+	// Manifests holds the per-architecture/per-OS digests advertised by
+	// this resource's OCI image index, if it's a multi-arch docker
+	// resource. It is empty for non-docker resources and for docker
+	// resources that only have a single manifest.
+	Manifests []DockerManifestRef `json:",omitempty"`
 }
 
 // ResourceUploadResponse holds the result of a post or a put to /id/resources/name.
@@ -386,6 +560,73 @@ type ResourceUploadResponse struct {
 	Revision int
 }
 
+// Platform identifies the OS and architecture a DockerManifestRef applies
+// to, as found in an OCI image index.
+type Platform struct {
+	// OS is the operating system the manifest targets, e.g. "linux".
+	OS string `json:"os"`
+
+	// Architecture is the CPU architecture the manifest targets, e.g.
+	// "amd64" or "arm64".
+	Architecture string `json:"architecture"`
+
+	// Variant further qualifies Architecture, e.g. "v7" for armv7.
+	Variant string `json:"variant,omitempty"`
+}
+
+// DockerManifestRef identifies one platform-specific manifest within a
+// multi-arch docker resource's OCI image index.
+type DockerManifestRef struct {
+	// Digest holds the digest of the platform-specific manifest, in the
+	// form "sha256:hexbytes".
+	Digest string `json:"digest"`
+
+	// MediaType holds the manifest's media type, distinguishing an OCI
+	// image manifest from a Docker distribution manifest; see
+	// OCIManifestMediaType and DockerManifestMediaType.
+	MediaType string `json:"mediaType"`
+
+	// Platform identifies the OS/architecture this manifest is for.
+	Platform Platform `json:"platform"`
+
+	// Size is the size of the manifest itself, in bytes.
+	Size int64 `json:"size"`
+}
+
+const (
+	// OCIManifestMediaType is the media type of an OCI image manifest.
+	OCIManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// DockerManifestMediaType is the media type of a Docker distribution
+	// manifest.
+	DockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// DockerAuth holds the credentials needed to pull a docker resource's
+// image, either as a plain username/password or as a bearer token obtained
+// from a token-issuing registry; see
+// https://docs.docker.com/registry/spec/auth/token/#requesting-a-token.
+type DockerAuth struct {
+	// Username and Password hold basic-auth style credentials. They are
+	// unset when Token is in use.
+	Username string `json:",omitempty"`
+	Password string `json:",omitempty"`
+
+	// Token holds a bearer token to present to the registry instead of
+	// basic auth.
+	Token string `json:",omitempty"`
+
+	// Scope holds the scope the token was issued for, e.g.
+	// "repository:samalba/my-app:pull".
+	Scope string `json:",omitempty"`
+
+	// Service holds the name of the service that issued the token.
+	Service string `json:",omitempty"`
+
+	// Expires holds when the token stops being valid.
+	Expires time.Time `json:",omitempty"`
+}
+
 // DockerResourceUploadRequest holds the body of a POST to /:id/resources/:name
 // when the resource is a docker image.
 type DockerResourceUploadRequest struct {
@@ -393,8 +634,19 @@ type DockerResourceUploadRequest struct {
 	// contained within the charm store's registry. If this is empty, the
 	// image should have been uploaded to the charm store's registry.
 	ImageName string
+
 	// Digest holds the digest of the image, in the form "sha256:hexbytes".
+	// For a multi-arch image it is the digest of the image index itself;
+	// the per-platform digests are given in Manifests.
 	Digest string
+
+	// MediaType holds the media type of the manifest identified by
+	// Digest; see OCIManifestMediaType and DockerManifestMediaType.
+	MediaType string `json:",omitempty"`
+
+	// Manifests holds the per-architecture/per-OS manifests making up the
+	// image, if it's a multi-arch image published as an OCI image index.
+	Manifests []DockerManifestRef `json:",omitempty"`
 }
 
 // DockerInfoResponse holds the result of a get of /:id/resources/:name/docker-info
@@ -402,12 +654,17 @@ type DockerInfoResponse struct {
 	// ImageName holds the image name (including host) of the resource in the docker registry.
 	ImageName string
 
-	// Username holds the username to use in the docker auth information.
-	// (see https://docs.docker.com/registry/spec/auth/token/#requesting-a-token).
-	Username string
+	// Auth holds the credentials needed to pull ImageName.
+	Auth DockerAuth
 
-	// Password holds the password to use in the docker auth information.
-	Password string
+	// MediaType holds the media type of the manifest identified by the
+	// resource's digest; see OCIManifestMediaType and
+	// DockerManifestMediaType.
+	MediaType string `json:",omitempty"`
+
+	// Manifests holds the per-architecture/per-OS manifests making up the
+	// image, if it's a multi-arch image published as an OCI image index.
+	Manifests []DockerManifestRef `json:",omitempty"`
 }
 
 // CharmRevision holds the revision number of a charm and any error
@@ -559,3 +816,108 @@ type UploadInfoResponse struct {
 	// MaxParts holds the maximum number of parts.
 	MaxParts int
 }
+
+// ErrorCode holds the class of an error returned by the charm store HTTP
+// API, allowing callers to distinguish error cases without parsing
+// Error.Message.
+type ErrorCode string
+
+const (
+	// ErrNotFound reports that the requested entity, resource or
+	// endpoint was not found.
+	ErrNotFound ErrorCode = "not found"
+
+	// ErrMetadataNotFound reports that a metadata endpoint was requested
+	// for an entity that doesn't have that metadata.
+	ErrMetadataNotFound ErrorCode = "metadata not found"
+
+	// ErrForbidden reports that the authenticated user isn't allowed to
+	// perform the requested operation.
+	ErrForbidden ErrorCode = "forbidden"
+
+	// ErrUnauthorized reports that the request requires authentication.
+	ErrUnauthorized ErrorCode = "unauthorized"
+
+	// ErrBadRequest reports that the request was malformed in some way.
+	ErrBadRequest ErrorCode = "bad request"
+
+	// ErrAlreadyExists reports that the entity being created already
+	// exists.
+	ErrAlreadyExists ErrorCode = "already exists"
+
+	// ErrEntityIdNotAllowed reports that a fully-qualified entity id was
+	// given where a partial id was required, or vice versa.
+	ErrEntityIdNotAllowed ErrorCode = "entity id not allowed"
+
+	// ErrResourceRequired reports that a request referred to a resource
+	// that must be set but wasn't.
+	ErrResourceRequired ErrorCode = "resource required"
+
+	// ErrInvalidEntity reports that an entity failed validation, for
+	// example because a channel it was published to is invalid.
+	ErrInvalidEntity ErrorCode = "invalid entity"
+
+	// ErrServiceUnavailable reports that the charm store is temporarily
+	// unable to handle the request.
+	ErrServiceUnavailable ErrorCode = "service unavailable"
+)
+
+// Error holds the body of an error response returned by the charm store
+// HTTP API, as documented at
+// https://github.com/juju/charmstore/blob/v5-unstable/docs/API.md#errors
+type Error struct {
+	// Message holds the human-readable description of the error.
+	Message string
+
+	// Code classifies the error; see the ErrNotFound family of
+	// constants. It may be empty if the server didn't classify the
+	// error.
+	Code ErrorCode
+
+	// Info holds further per-field errors associated with this one, for
+	// example one entry per invalid resource revision in a
+	// PublishRequest.Resources. It is nil unless the server reported any.
+	Info map[string]*Error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+// ErrorCode returns e's error code, satisfying the same convention used by
+// juju/juju and juju/errors so that callers can type-switch on an
+// interface instead of this concrete type.
+func (e *Error) ErrorCode() string {
+	return string(e.Code)
+}
+
+// Unwrap returns nil: Error is a leaf in the charm store's own error
+// model, not a wrapper around another Go error. It's defined so that
+// errors.Is and errors.As terminate cleanly when they reach an *Error
+// with no further cause, rather than panicking on a missing method.
+func (e *Error) Unwrap() error {
+	return nil
+}
+
+// Cause returns e itself, satisfying the github.com/juju/errors Causer
+// interface so that juju/errors.Cause(err) unwraps to the charm store
+// error rather than an intermediate wrapper.
+func (e *Error) Cause() error {
+	return e
+}
+
+// UnmarshalError decodes the JSON body of a charm store error response
+// into an *Error, populating its nested Info map so that, for example, a
+// caller publishing a bundle with several bad resource revisions can
+// iterate per-resource errors instead of parsing Message.
+func UnmarshalError(body []byte) (*Error, error) {
+	var e Error
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal error response: %v", err)
+	}
+	return &e, nil
+}
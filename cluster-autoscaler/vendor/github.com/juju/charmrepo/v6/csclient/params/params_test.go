@@ -0,0 +1,85 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package params
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseChannelRoundTripsThroughString(t *testing.T) {
+	tests := []string{
+		"stable",
+		"edge",
+		"latest/stable",
+		"latest/edge",
+		"2.9/candidate",
+		"1.25/edge/hotfix-abc",
+		"latest/edge/my-branch",
+	}
+	for _, s := range tests {
+		ch, err := ParseChannel(s)
+		if err != nil {
+			t.Errorf("ParseChannel(%q) returned error: %v", s, err)
+			continue
+		}
+		ch2, err := ParseChannel(ch.String())
+		if err != nil {
+			t.Errorf("ParseChannel(%q) (round-trip of %q) returned error: %v", ch.String(), s, err)
+			continue
+		}
+		if ch2 != ch {
+			t.Errorf("round-tripping %q through String() gave %q, want %q", s, ch2, ch)
+		}
+	}
+}
+
+func TestParseChannelLatestTrackWithBranchKeepsRiskAndBranch(t *testing.T) {
+	ch, err := ParseChannel("latest/edge/my-branch")
+	if err != nil {
+		t.Fatalf("ParseChannel() returned error: %v", err)
+	}
+	if got := ch.Track(); got != "latest" {
+		t.Errorf("Track() = %q, want %q", got, "latest")
+	}
+	if got := ch.Risk(); got != "edge" {
+		t.Errorf("Risk() = %q, want %q", got, "edge")
+	}
+	if got := ch.Branch(); got != "my-branch" {
+		t.Errorf("Branch() = %q, want %q", got, "my-branch")
+	}
+}
+
+func TestParseChannelUnknownRiskIsRejected(t *testing.T) {
+	if _, err := ParseChannel("latest/not-a-risk"); err == nil {
+		t.Errorf("ParseChannel() with an invalid risk did not return an error")
+	}
+}
+
+func TestParseChannelTooManyComponentsIsRejected(t *testing.T) {
+	if _, err := ParseChannel("a/b/c/d"); err == nil {
+		t.Errorf("ParseChannel() with too many components did not return an error")
+	}
+}
+
+func TestChannelJSONRoundTrips(t *testing.T) {
+	ch, err := ParseChannel("1.25/edge/hotfix-abc")
+	if err != nil {
+		t.Fatalf("ParseChannel() returned error: %v", err)
+	}
+	req := PublishRequest{Channels: []Channel{ch}}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+
+	var got PublishRequest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() returned error: %v", err)
+	}
+	if len(got.Channels) != 1 || got.Channels[0] != ch {
+		t.Errorf("got Channels %v, want [%v]", got.Channels, ch)
+	}
+}
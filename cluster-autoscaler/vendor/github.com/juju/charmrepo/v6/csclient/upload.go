@@ -0,0 +1,295 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package csclient provides a high-level client for the charm store's
+// multipart upload HTTP API, built on top of the wire types in
+// csclient/params.
+package csclient
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/juju/charmrepo/v6/csclient/params"
+)
+
+// uploadClient is the subset of the charm store's upload API that
+// MultipartUploader needs, split out so it can be exercised against a fake
+// in tests instead of a real charm store connection.
+type uploadClient interface {
+	// NewUpload starts a new multipart upload, returning its upload id and
+	// the part-size limits the charm store will accept.
+	NewUpload() (params.UploadInfoResponse, error)
+
+	// UploadInfo returns the current state of an in-progress upload, so a
+	// resumed upload can see which parts have already completed.
+	UploadInfo(uploadId string) (params.UploadInfoResponse, error)
+
+	// PutPart uploads the part at the given offset of uploadId, verified
+	// against the given SHA-384 hash.
+	PutPart(uploadId string, partNumber int, offset, size int64, hash string, r io.ReadSeeker) error
+
+	// FinishUpload finalizes uploadId once every part is complete,
+	// returning the hash of the whole reassembled blob.
+	FinishUpload(uploadId string, parts []params.Part) (params.FinishUploadResponse, error)
+
+	// PutArchive posts the finished, hashed archive, completing the upload.
+	PutArchive(uploadId, hash string, size int64) error
+}
+
+// temporaryError is implemented by client errors that carry an HTTP status
+// code and the raw JSON body of the charm store's error response, letting
+// uploadPart decide whether a PutPart failure is worth retrying and decode
+// a structured params.Error once it gives up.
+type temporaryError interface {
+	StatusCode() int
+	Body() []byte
+}
+
+// Store checkpoints in-progress upload state so an interrupted upload can
+// be resumed without re-uploading completed parts.
+type Store interface {
+	// Load returns the upload id and completed parts previously saved for
+	// key, or ok=false if no upload is in progress.
+	Load(key string) (uploadId string, parts []params.Part, ok bool, err error)
+
+	// Save records uploadId and the currently completed parts for key.
+	Save(key string, uploadId string, parts []params.Part) error
+}
+
+// ProgressFunc is called as an upload makes progress, reporting the total
+// number of bytes uploaded so far out of the blob's total size.
+type ProgressFunc func(uploaded, total int64)
+
+// MultipartUploader drives a resumable, concurrent multipart upload on top
+// of the charm store's raw /upload endpoints, leaving callers to supply
+// only the blob and a place to checkpoint progress.
+type MultipartUploader struct {
+	client uploadClient
+	store  Store
+
+	// Concurrency is the number of parts uploaded at once. A value <= 0
+	// is treated as 1.
+	Concurrency int
+
+	// Progress, if non-nil, is called after every part completes.
+	Progress ProgressFunc
+
+	// MaxRetries is the total number of attempts, the first included, made
+	// to upload a part that keeps getting 5xx responses before the upload
+	// gives up. A value <= 0 is treated as 3.
+	MaxRetries int
+}
+
+// NewMultipartUploader returns a MultipartUploader that uploads through
+// client and checkpoints progress to store.
+func NewMultipartUploader(client uploadClient, store Store) *MultipartUploader {
+	return &MultipartUploader{client: client, store: store}
+}
+
+// Upload uploads the size bytes readable through r, identified by key for
+// checkpointing purposes, and returns the finished archive's hash. If a
+// previous call was interrupted partway through the same key, Upload
+// resumes it instead of starting over.
+func (u *MultipartUploader) Upload(key string, r io.ReaderAt, size int64) (params.FinishUploadResponse, error) {
+	info, parts, err := u.resume(key, size)
+	if err != nil {
+		return params.FinishUploadResponse{}, fmt.Errorf("cannot resume upload: %v", err)
+	}
+
+	offsets, err := partOffsets(size, info.MinPartSize, info.MaxPartSize, info.MaxParts)
+	if err != nil {
+		return params.FinishUploadResponse{}, fmt.Errorf("cannot split upload into parts: %v", err)
+	}
+	remaining := make([]int, 0, len(offsets))
+	for i := range offsets {
+		if i >= len(parts) || !parts[i].Complete {
+			remaining = append(remaining, i)
+		}
+	}
+
+	var uploaded int64
+	for _, p := range parts {
+		if p.Complete {
+			uploaded += p.Size
+		}
+	}
+	u.reportProgress(uploaded, size)
+
+	// results must be buffered to hold every dispatched part: the dispatch
+	// loop below launches a goroutine per remaining part before this
+	// function ever reads from results, gated only by sem. An unbuffered
+	// channel would deadlock as soon as len(remaining) exceeds
+	// u.concurrency(): sem blocks the loop once concurrency() goroutines
+	// are in flight, but the goroutine that needs to finish and free a sem
+	// slot is itself blocked sending its result to a channel nothing is
+	// draining yet.
+	results := make(chan partResult, len(remaining))
+	sem := make(chan struct{}, u.concurrency())
+	for _, i := range remaining {
+		i := i
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			part, err := u.uploadPart(info.UploadId, i, offsets, size, r)
+			results <- partResult{index: i, part: part, err: err}
+		}()
+	}
+
+	var firstErr error
+	for range remaining {
+		res := <-results
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		parts[res.index] = res.part
+		uploaded += res.part.Size
+		u.reportProgress(uploaded, size)
+		if err := u.store.Save(key, info.UploadId, parts); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cannot checkpoint upload progress: %v", err)
+		}
+	}
+	if firstErr != nil {
+		return params.FinishUploadResponse{}, firstErr
+	}
+
+	return u.client.FinishUpload(info.UploadId, parts)
+}
+
+type partResult struct {
+	index int
+	part  params.Part
+	err   error
+}
+
+// resume either recovers the upload id and completed parts of an
+// in-progress upload for key, or starts a fresh one.
+func (u *MultipartUploader) resume(key string, size int64) (params.UploadInfoResponse, []params.Part, error) {
+	if uploadId, parts, ok, err := u.store.Load(key); err != nil {
+		return params.UploadInfoResponse{}, nil, err
+	} else if ok {
+		info, err := u.client.UploadInfo(uploadId)
+		if err != nil {
+			return params.UploadInfoResponse{}, nil, err
+		}
+		return info, parts, nil
+	}
+
+	info, err := u.client.NewUpload()
+	if err != nil {
+		return params.UploadInfoResponse{}, nil, err
+	}
+	offsets, err := partOffsets(size, info.MinPartSize, info.MaxPartSize, info.MaxParts)
+	if err != nil {
+		return params.UploadInfoResponse{}, nil, fmt.Errorf("cannot split upload into parts: %v", err)
+	}
+	n := len(offsets)
+	if err := u.store.Save(key, info.UploadId, make([]params.Part, n)); err != nil {
+		return params.UploadInfoResponse{}, nil, fmt.Errorf("cannot checkpoint new upload: %v", err)
+	}
+	return info, make([]params.Part, n), nil
+}
+
+// uploadPart hashes and uploads the part at offsets[i], retrying on 5xx
+// responses with exponential backoff.
+func (u *MultipartUploader) uploadPart(uploadId string, i int, offsets []int64, size int64, r io.ReaderAt) (params.Part, error) {
+	offset := offsets[i]
+	partSize := size - offset
+	if i < len(offsets)-1 {
+		partSize = offsets[i+1] - offset
+	}
+
+	hash := sha512.New384()
+	if _, err := io.Copy(hash, io.NewSectionReader(r, offset, partSize)); err != nil {
+		return params.Part{}, fmt.Errorf("cannot hash part %d: %v", i, err)
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	var lastErr error
+	for attempt := 0; attempt < u.maxRetries(); attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff(attempt))
+		}
+		section := io.NewSectionReader(r, offset, partSize)
+		lastErr = u.client.PutPart(uploadId, i, offset, partSize, sum, section)
+		if lastErr == nil {
+			return params.Part{Hash: sum, Size: partSize, Offset: offset, Complete: true}, nil
+		}
+		if te, ok := lastErr.(temporaryError); !ok || te.StatusCode() < 500 {
+			break
+		}
+	}
+	if te, ok := lastErr.(temporaryError); ok {
+		if perr, err := params.UnmarshalError(te.Body()); err == nil {
+			return params.Part{}, fmt.Errorf("cannot upload part %d: %w", i, perr)
+		}
+	}
+	return params.Part{}, fmt.Errorf("cannot upload part %d: %v", i, lastErr)
+}
+
+func (u *MultipartUploader) concurrency() int {
+	if u.Concurrency <= 0 {
+		return 1
+	}
+	return u.Concurrency
+}
+
+func (u *MultipartUploader) maxRetries() int {
+	if u.MaxRetries <= 0 {
+		return 3
+	}
+	return u.MaxRetries
+}
+
+func (u *MultipartUploader) reportProgress(uploaded, total int64) {
+	if u.Progress != nil {
+		u.Progress(uploaded, total)
+	}
+}
+
+// backoff returns the delay before retry attempt n (1-based), doubling
+// each time starting at 500ms.
+func backoff(n int) time.Duration {
+	return (500 * time.Millisecond) << uint(n-1)
+}
+
+// partOffsets splits a blob of the given size into parts no smaller than
+// minSize (except the last) and no larger than maxSize, returning each
+// part's starting offset. It uses the smallest part size allowed, growing
+// it only as far as needed to stay within maxParts, returning an error if no part size satisfies all three constraints at
+// once.
+func partOffsets(size, minSize, maxSize int64, maxParts int) ([]int64, error) {
+	if size <= maxSize {
+		return []int64{0}, nil
+	}
+
+	partSize := minSize
+	if partSize <= 0 {
+		partSize = maxSize
+	}
+	n := (size + partSize - 1) / partSize
+	if maxParts > 0 && int(n) > maxParts {
+		n = int64(maxParts)
+		partSize = (size + n - 1) / n
+		if partSize > maxSize {
+			// Clamping partSize back down to maxSize would push the part
+			// count back over maxParts, the very thing n was recomputed to
+			// avoid; there's no part size that satisfies minSize, maxSize
+			// and maxParts simultaneously; so this is a caller configuration
+			// problem, not something to silently clamp past.
+			return nil, fmt.Errorf("cannot split %d bytes into at most %d parts without exceeding the %d byte max part size", size, maxParts, maxSize)
+		}
+	}
+
+	offsets := make([]int64, 0, n)
+	for offset := int64(0); offset < size; offset += partSize {
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package csclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/charmrepo/v6/csclient/params"
+)
+
+// fakeTemporaryError is a 500 response from the charm store carrying a JSON
+// body, satisfying temporaryError so uploadPart's retry-exhausted path can
+// decode it.
+type fakeTemporaryError struct {
+	status int
+	body   []byte
+}
+
+func (e *fakeTemporaryError) Error() string   { return "fake upload error" }
+func (e *fakeTemporaryError) StatusCode() int { return e.status }
+func (e *fakeTemporaryError) Body() []byte    { return e.body }
+
+// fakeUploadClient is a minimal uploadClient whose PutPart always fails with
+// a fakeTemporaryError, so uploadPart exhausts its retries and falls into
+// the decode path being tested.
+type fakeUploadClient struct {
+	putPartErr error
+}
+
+func (c *fakeUploadClient) NewUpload() (params.UploadInfoResponse, error) {
+	return params.UploadInfoResponse{
+		UploadId:    "upload-1",
+		MinPartSize: 1,
+		MaxPartSize: 1024,
+		MaxParts:    10,
+	}, nil
+}
+
+func (c *fakeUploadClient) UploadInfo(uploadId string) (params.UploadInfoResponse, error) {
+	return params.UploadInfoResponse{}, errors.New("not implemented")
+}
+
+func (c *fakeUploadClient) PutPart(uploadId string, partNumber int, offset, size int64, hash string, r io.ReadSeeker) error {
+	return c.putPartErr
+}
+
+func (c *fakeUploadClient) FinishUpload(uploadId string, parts []params.Part) (params.FinishUploadResponse, error) {
+	return params.FinishUploadResponse{}, errors.New("not implemented")
+}
+
+func (c *fakeUploadClient) PutArchive(uploadId, hash string, size int64) error {
+	return errors.New("not implemented")
+}
+
+type fakeStore struct{}
+
+func (fakeStore) Load(key string) (string, []params.Part, bool, error) { return "", nil, false, nil }
+func (fakeStore) Save(key, uploadId string, parts []params.Part) error { return nil }
+
+// fakeMultipartUploadClient supports a full successful multi-part upload,
+// unlike fakeUploadClient above, which is built only to exercise PutPart's
+// failure path.
+type fakeMultipartUploadClient struct {
+	mu    sync.Mutex
+	parts int
+}
+
+func (c *fakeMultipartUploadClient) NewUpload() (params.UploadInfoResponse, error) {
+	return params.UploadInfoResponse{UploadId: "upload-1", MinPartSize: 4, MaxPartSize: 4, MaxParts: 100}, nil
+}
+
+func (c *fakeMultipartUploadClient) UploadInfo(uploadId string) (params.UploadInfoResponse, error) {
+	return params.UploadInfoResponse{}, errors.New("not implemented")
+}
+
+func (c *fakeMultipartUploadClient) PutPart(uploadId string, partNumber int, offset, size int64, hash string, r io.ReadSeeker) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parts++
+	return nil
+}
+
+func (c *fakeMultipartUploadClient) FinishUpload(uploadId string, parts []params.Part) (params.FinishUploadResponse, error) {
+	return params.FinishUploadResponse{}, nil
+}
+
+func (c *fakeMultipartUploadClient) PutArchive(uploadId, hash string, size int64) error {
+	return errors.New("not implemented")
+}
+
+func TestUploadMultiplePartsAtDefaultConcurrencyDoesNotDeadlock(t *testing.T) {
+	client := &fakeMultipartUploadClient{}
+	u := NewMultipartUploader(client, fakeStore{})
+	// Concurrency is left at its zero value (default 1). MaxPartSize of 4
+	// above splits this 20-byte blob into 5 parts, reproducing the
+	// len(remaining) > concurrency() deadlock if Upload's dispatch loop
+	// isn't interleaved with result consumption.
+	blob := bytes.NewReader([]byte("01234567890123456789"))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := u.Upload("some-key", blob, int64(blob.Len()))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Upload() returned error: %v", err)
+		}
+		if client.parts != 5 {
+			t.Errorf("got %d parts uploaded, want 5", client.parts)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Upload() did not return within 3s, deadlocked dispatching more parts than Concurrency allows in flight")
+	}
+}
+
+func TestUploadPartDecodesChargeStoreErrorBody(t *testing.T) {
+	wantErr := &params.Error{
+		Message: "invalid resource revisions",
+		Code:    params.ErrBadRequest,
+		Info: map[string]*params.Error{
+			"resource-1": {Message: "revision 3 not found", Code: params.ErrNotFound},
+		},
+	}
+	body, err := json.Marshal(wantErr)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+
+	client := &fakeUploadClient{putPartErr: &fakeTemporaryError{status: 503, body: body}}
+	u := NewMultipartUploader(client, fakeStore{})
+	u.MaxRetries = 1
+
+	_, err = u.Upload("some-key", strings.NewReader("x"), 1)
+	if err == nil {
+		t.Fatalf("Upload() returned no error, want a decoded params.Error")
+	}
+
+	var perr *params.Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("errors.As(%v, &*params.Error) = false, want true", err)
+	}
+	if perr.Message != wantErr.Message || perr.Code != wantErr.Code {
+		t.Errorf("got Error{Message: %q, Code: %q}, want Error{Message: %q, Code: %q}",
+			perr.Message, perr.Code, wantErr.Message, wantErr.Code)
+	}
+	if len(perr.Info) != 1 || perr.Info["resource-1"] == nil || perr.Info["resource-1"].Message != "revision 3 not found" {
+		t.Errorf("got Info %v, want a populated entry for resource-1", perr.Info)
+	}
+}
+
+func TestPartOffsetsRespectsMaxParts(t *testing.T) {
+	// minSize is small enough that the naive part count would exceed
+	// maxParts, but maxSize leaves enough room that the recomputed partSize
+	// still fits under it, so this should succeed within maxParts parts.
+	offsets, err := partOffsets(100<<20, 1, 10<<20, 10)
+	if err != nil {
+		t.Fatalf("partOffsets() returned error: %v", err)
+	}
+	if len(offsets) > 10 {
+		t.Errorf("got %d parts, want at most 10", len(offsets))
+	}
+}
+
+func TestPartOffsetsRejectsUnsatisfiableConstraints(t *testing.T) {
+	// minSize=1 picks a partSize of 1 byte, ballooning the naive part count
+	// to ~1e9; recomputing partSize to fit in maxParts gives ~102MB, which
+	// still exceeds maxSize (10MB), and clamping it back down to maxSize
+	// would in turn push the part count back over maxParts. There's no
+	// valid split, so this is the reviewer's reported regression case.
+	if _, err := partOffsets(1<<30, 1, 10<<20, 10); err == nil {
+		t.Errorf("partOffsets() with unsatisfiable constraints did not return an error")
+	}
+}
+
+func TestPartOffsetsSinglePartCoversWholeBlob(t *testing.T) {
+	offsets, err := partOffsets(5, 1, 10, 10)
+	if err != nil {
+		t.Fatalf("partOffsets() returned error: %v", err)
+	}
+	if len(offsets) != 1 || offsets[0] != 0 {
+		t.Errorf("got %v, want a single offset at 0", offsets)
+	}
+}
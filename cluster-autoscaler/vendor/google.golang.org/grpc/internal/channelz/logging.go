@@ -21,7 +21,6 @@ package channelz
 import (
 	"fmt"
 
-<<<<<<< HEAD
 	"google.golang.org/grpc/grpclog"
 )
 
@@ -56,45 +55,10 @@ func Infof(l grpclog.DepthLoggerV2, id int64, format string, args ...interface{}
 func Warning(l grpclog.DepthLoggerV2, id int64, args ...interface{}) {
 	if IsOn() {
 		AddTraceEvent(l, id, 1, &TraceEventDesc{
-=======
-	"google.golang.org/grpc/internal/grpclog"
-)
-
-// Info logs through grpclog.Info and adds a trace event if channelz is on.
-func Info(id int64, args ...interface{}) {
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
-			Desc:     fmt.Sprint(args...),
-			Severity: CtINFO,
-		})
-	} else {
-		grpclog.InfoDepth(1, args...)
-	}
-}
-
-// Infof logs through grpclog.Infof and adds a trace event if channelz is on.
-func Infof(id int64, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
-			Desc:     msg,
-			Severity: CtINFO,
-		})
-	} else {
-		grpclog.InfoDepth(1, msg)
-	}
-}
-
-// Warning logs through grpclog.Warning and adds a trace event if channelz is on.
-func Warning(id int64, args ...interface{}) {
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
->>>>>>> 1cb7c9a8c04b7de79c2dd46f84bd5239eed4ee16
 			Desc:     fmt.Sprint(args...),
 			Severity: CtWarning,
 		})
 	} else {
-<<<<<<< HEAD
 		l.WarningDepth(1, args...)
 	}
 }
@@ -104,22 +68,10 @@ func Warningf(l grpclog.DepthLoggerV2, id int64, format string, args ...interfac
 	msg := fmt.Sprintf(format, args...)
 	if IsOn() {
 		AddTraceEvent(l, id, 1, &TraceEventDesc{
-=======
-		grpclog.WarningDepth(1, args...)
-	}
-}
-
-// Warningf logs through grpclog.Warningf and adds a trace event if channelz is on.
-func Warningf(id int64, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
->>>>>>> 1cb7c9a8c04b7de79c2dd46f84bd5239eed4ee16
 			Desc:     msg,
 			Severity: CtWarning,
 		})
 	} else {
-<<<<<<< HEAD
 		l.WarningDepth(1, msg)
 	}
 }
@@ -128,21 +80,10 @@ func Warningf(id int64, format string, args ...interface{}) {
 func Error(l grpclog.DepthLoggerV2, id int64, args ...interface{}) {
 	if IsOn() {
 		AddTraceEvent(l, id, 1, &TraceEventDesc{
-=======
-		grpclog.WarningDepth(1, msg)
-	}
-}
-
-// Error logs through grpclog.Error and adds a trace event if channelz is on.
-func Error(id int64, args ...interface{}) {
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
->>>>>>> 1cb7c9a8c04b7de79c2dd46f84bd5239eed4ee16
 			Desc:     fmt.Sprint(args...),
 			Severity: CtError,
 		})
 	} else {
-<<<<<<< HEAD
 		l.ErrorDepth(1, args...)
 	}
 }
@@ -152,25 +93,10 @@ func Errorf(l grpclog.DepthLoggerV2, id int64, format string, args ...interface{
 	msg := fmt.Sprintf(format, args...)
 	if IsOn() {
 		AddTraceEvent(l, id, 1, &TraceEventDesc{
-=======
-		grpclog.ErrorDepth(1, args...)
-	}
-}
-
-// Errorf logs through grpclog.Errorf and adds a trace event if channelz is on.
-func Errorf(id int64, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	if IsOn() {
-		AddTraceEvent(id, 1, &TraceEventDesc{
->>>>>>> 1cb7c9a8c04b7de79c2dd46f84bd5239eed4ee16
 			Desc:     msg,
 			Severity: CtError,
 		})
 	} else {
-<<<<<<< HEAD
 		l.ErrorDepth(1, msg)
-=======
-		grpclog.ErrorDepth(1, msg)
->>>>>>> 1cb7c9a8c04b7de79c2dd46f84bd5239eed4ee16
 	}
 }